@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/wgu/go-flickr/flickr"
+)
+
+func main() {
+	var consumerKey, consumerSecret, callback, perms, out string
+	flag.StringVar(&consumerKey, "oauth_consumer_key", "", "The API Key flickr gives.")
+	flag.StringVar(&consumerSecret, "api_secret", "", "The API secret flickr gives along with the key.")
+	flag.StringVar(&callback, "callback", flickr.OutOfBand, "\"oob\" to paste the verifier by hand, or a http://localhost:port/path URL to receive it automatically.")
+	flag.StringVar(&perms, "perms", "write", "The permission to ask for: read, write or delete.")
+	flag.StringVar(&out, "out", "", "Optional. File to save the resulting oauth_token and secret to.")
+	flag.Parse()
+	if consumerKey == "" {
+		flickr.CheckErr(errors.New("Missing oauth_consumer_key"))
+	}
+	if consumerSecret == "" {
+		flickr.CheckErr(errors.New("Missing api_secret"))
+	}
+
+	client := flickr.NewClient(nil, "")
+	requestToken, err := client.GetRequestToken(consumerKey, consumerSecret, callback)
+	flickr.CheckErr(err)
+
+	fmt.Println("Open the following URL in a browser and authorize the application:")
+	fmt.Println(client.AuthorizeURL(requestToken, perms))
+
+	var verifier string
+	if callback == flickr.OutOfBand {
+		verifier, err = readVerifier()
+	} else {
+		verifier, err = waitForCallback(callback, requestToken.Token)
+	}
+	flickr.CheckErr(err)
+
+	accessToken, err := client.GetAccessToken(consumerKey, consumerSecret, requestToken, verifier)
+	flickr.CheckErr(err)
+
+	result := fmt.Sprintf("oauth_consumer_key=%s\noauth_token=%s\nsecret=%s&%s\n",
+		consumerKey, accessToken.Token, consumerSecret, accessToken.Secret)
+	fmt.Println("Authorized as " + accessToken.Username + " (" + accessToken.UserNsid + ")")
+	fmt.Print(result)
+	if out != "" {
+		flickr.CheckErr(ioutil.WriteFile(out, []byte(result), 0600))
+		fmt.Println("Saved to " + out)
+	}
+}
+
+func readVerifier() (string, error) {
+	fmt.Print("Enter the verification code: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	verifier := strings.TrimSpace(line)
+	if verifier == "" {
+		return "", errors.New("Empty verification code")
+	}
+	return verifier, nil
+}
+
+// waitForCallback serves the callback URL locally until Flickr redirects the
+// browser back with the verifier for token.
+func waitForCallback(callback string, token string) (string, error) {
+	u, err := url.Parse(callback)
+	if err != nil {
+		return "", err
+	}
+	listener, err := net.Listen("tcp", u.Host)
+	if err != nil {
+		return "", err
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	verifiers := make(chan string, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("oauth_token") != token || query.Get("oauth_verifier") == "" {
+			http.Error(w, "Unexpected callback", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintln(w, "Authorized. You can close this window.")
+		select {
+		case verifiers <- query.Get("oauth_verifier"):
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+
+	fmt.Println("Waiting for the callback on " + callback)
+	return <-verifiers, nil
+}
@@ -1,13 +1,15 @@
 package main
 
 import (
-	"encoding/xml"
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/wgu/go-flickr/flickr"
 )
@@ -15,16 +17,14 @@ import (
 func main() {
 	requestTemplate, err := flickr.NewRequestFromCmd()
 	flickr.CheckErr(err)
+	slog.SetDefault(requestTemplate.Logger)
+	client := requestTemplate.NewClient()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	args := map[string]string{
-		"method": "flickr.photosets.getList",
-	}
-	request := flickr.NewRequest(http.MethodGet, requestTemplate.Auth, args, requestTemplate.Secret)
-	response, err := request.ExecuteWithRetry(2, time.Second)
-	flickr.CheckErr(err, response)
-	var photoSets flickr.Photosets
-	flickr.CheckErr(xml.Unmarshal([]byte(response), &photoSets), response)
-	for _, photoSet := range photoSets.Photoset {
+	photoSets := client.Photosets().GetListPaginator(ctx, "", 0)
+	for photoSets.Next() {
+		photoSet := photoSets.Item()
 		folderName := photoSet.Title
 		if existsFolder(folderName, "/Users/sgu/workspace/web-crawler/", "oumeirenti", "yazhourenti", "a4you", "hanguorenti", "ribenrenti", requestTemplate.Dir) {
 			fmt.Println("Skipped " + folderName)
@@ -33,40 +33,50 @@ func main() {
 		fmt.Println("Downloading " + folderName)
 		folder := "/Users/sgu/workspace/web-crawler/" + requestTemplate.Dir + "/" + folderName + "/"
 		os.MkdirAll(folder, os.ModePerm)
-		for i := 1; ; i++ {
-			args := map[string]string{
-				"method":      "flickr.photosets.getPhotos",
-				"user_id":     "161286677@N08",
-				"photoset_id": photoSet.Id,
-				"extras":      "url_o, original_format",
-				"page":        strconv.Itoa(i),
-			}
-			request := flickr.NewRequest(http.MethodGet, requestTemplate.Auth, args, requestTemplate.Secret)
-			response, err := request.ExecuteWithRetry(2, time.Second)
-			flickr.CheckErr(err, response)
-			var photoSet flickr.Photoset
-			flickr.CheckErr(xml.Unmarshal([]byte(response), &photoSet), response)
-			index := 1
-			for _, photo := range photoSet.Photo {
-				resp, err := http.Get(photo.UrlO)
+		photos := client.Photosets().GetPhotosPaginator(ctx, photoSet.Id, &flickr.PhotosetPhotosOptions{
+			UserId: "161286677@N08",
+			Extras: flickr.Extras{flickr.ExtraURLO, flickr.ExtraOriginalFormat, flickr.ExtraMedia},
+		}, 0)
+		index := 1
+		for photos.Next() {
+			photo := photos.Item()
+			source, format := photo.UrlO, photo.OriginalFormat
+			// url_o of a video is a still frame, the video itself is among its sizes
+			if photo.Media == "video" {
+				sizes, err := client.Photos().GetSizes(ctx, photo.Id)
 				flickr.CheckErr(err)
-				filePath := folder + photo.Title + "." + photo.OriginalFormat
-				if exists(filePath) {
-					filePath = folder + photo.Title + strconv.Itoa(index) + "." + photo.OriginalFormat
-					index++
+				video := sizes.Video()
+				if video == nil {
+					fmt.Println("No downloadable video for " + photo.Title)
+					continue
 				}
-				out, err := os.Create(filePath)
-				flickr.CheckErr(err)
-				_, err = io.Copy(out, resp.Body)
-				flickr.CheckErr(err)
-				resp.Body.Close()
-				out.Close()
+				source, format = video.Source, ""
+			}
+			getRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+			flickr.CheckErr(err)
+			resp, err := client.HTTPClient.Do(getRequest)
+			flickr.CheckErr(err)
+			if format == "" {
+				format = videoExtension(resp.Header.Get("Content-Type"))
 			}
-			// time.Sleep(time.Duration(rand.Intn(10)) * time.Second)
-			if i >= photoSet.Pages {
-				break
+			filePath := folder + photo.Title + "." + format
+			if exists(filePath) {
+				filePath = folder + photo.Title + strconv.Itoa(index) + "." + format
+				index++
 			}
+			out, err := os.Create(filePath)
+			flickr.CheckErr(err)
+			_, err = io.Copy(out, resp.Body)
+			flickr.CheckErr(err)
+			resp.Body.Close()
+			out.Close()
 		}
+		flickr.CheckErr(photos.Err())
+	}
+	flickr.CheckErr(photoSets.Err())
+	if client.RateLimiter != nil {
+		usage := client.RateLimiter.Usage()
+		fmt.Printf("Used %d API calls, %.0f left in the current burst\n", usage.Calls.Used, usage.Calls.Available)
 	}
 }
 
@@ -83,6 +93,24 @@ func existsFolder(folderName string, prefix string, paths ...string) bool {
 	return false
 }
 
+// videoExtension picks the file extension for a downloaded video, which
+// Flickr serves without one.
+func videoExtension(contentType string) string {
+	switch strings.TrimSpace(strings.Split(contentType, ";")[0]) {
+	case "video/quicktime":
+		return "mov"
+	case "video/x-msvideo", "video/avi":
+		return "avi"
+	case "video/x-ms-wmv":
+		return "wmv"
+	case "video/mpeg":
+		return "mpg"
+	case "video/x-m4v":
+		return "m4v"
+	}
+	return "mp4"
+}
+
 func exists(path string) bool {
 	if _, err := os.Stat(path); err == nil {
 		return true
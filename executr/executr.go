@@ -10,7 +10,7 @@ func main() {
 	requestTemplate, err := flickr.NewRequestFromCmd()
 	checkErr(err)
 	if requestTemplate.Dir == "" {
-		request := flickr.NewRequest(requestTemplate.HttpMethod, requestTemplate.Auth, requestTemplate.AdditionalArgs, requestTemplate.Secret)
+		request := requestTemplate.NewClient().NewRequest(requestTemplate.HttpMethod, requestTemplate.AdditionalArgs)
 		response, err := request.Execute()
 		fmt.Println(response)
 		checkErr(err)
@@ -0,0 +1,215 @@
+package flickr
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores the payloads of read-only API methods. Entries are grouped
+// by method so a write can drop everything it may have changed.
+type Cache interface {
+	Get(method string, key string) (payload string, ok bool)
+	Set(method string, key string, payload string, ttl time.Duration)
+	// Invalidate drops all entries of method.
+	Invalidate(method string)
+}
+
+// DefaultCacheTTLs are the methods cached when Client.CacheTTLs is nil.
+var DefaultCacheTTLs = map[string]time.Duration{
+	"flickr.photosets.getList":   10 * time.Minute,
+	"flickr.photosets.getInfo":   10 * time.Minute,
+	"flickr.photosets.getPhotos": 10 * time.Minute,
+	"flickr.collections.getTree": 10 * time.Minute,
+	"flickr.collections.getInfo": 10 * time.Minute,
+	"flickr.photos.getInfo":      5 * time.Minute,
+	"flickr.photos.getSizes":     time.Hour,
+	"flickr.photos.getExif":      time.Hour,
+}
+
+// cacheInvalidations maps the prefix of write methods to the read methods
+// whose results they can change. Writes not listed drop every cached method.
+var cacheInvalidations = map[string][]string{
+	"flickr.photosets.": {"flickr.photosets.getList", "flickr.photosets.getInfo", "flickr.photosets.getPhotos",
+		"flickr.collections.getTree"},
+	"flickr.collections.": {"flickr.collections.getTree", "flickr.collections.getInfo"},
+	"flickr.photos.": {"flickr.photos.getInfo", "flickr.photos.getSizes", "flickr.photos.getExif", "flickr.photos.search",
+		"flickr.photosets.getList", "flickr.photosets.getInfo", "flickr.photosets.getPhotos"},
+	uploadMethod:  {"flickr.photosets.getList", "flickr.photosets.getPhotos", "flickr.photos.search"},
+	replaceMethod: {"flickr.photos.getInfo", "flickr.photos.getSizes", "flickr.photos.getExif", "flickr.photos.search", "flickr.photosets.getPhotos"},
+}
+
+func (c *Client) cacheTTLs() map[string]time.Duration {
+	if c.CacheTTLs != nil {
+		return c.CacheTTLs
+	}
+	return DefaultCacheTTLs
+}
+
+// cacheTTL returns how long the result of request may be cached; 0 means
+// not at all.
+func (c *Client) cacheTTL(request *Request) time.Duration {
+	if c.Cache == nil || request.httpMethod != http.MethodGet {
+		return 0
+	}
+	return c.cacheTTLs()[request.args["method"]]
+}
+
+// cacheKey is the sorted query of the args that make up a request. Of the
+// oauth_ args only the token counts: the others are added by signing, so
+// they are missing before the first attempt and change with every retry.
+func cacheKey(args map[string]string) string {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		if k == "oauth_token" || !strings.HasPrefix(k, "oauth_") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(url.QueryEscape(k) + "=" + url.QueryEscape(args[k]))
+	}
+	return b.String()
+}
+
+// invalidate drops the cached methods a successful write of method can
+// have changed.
+func (c *Client) invalidate(method string) {
+	if c.Cache == nil {
+		return
+	}
+	for prefix, methods := range cacheInvalidations {
+		if strings.HasPrefix(method, prefix) {
+			for _, m := range methods {
+				c.Cache.Invalidate(m)
+			}
+			return
+		}
+	}
+	for m := range c.cacheTTLs() {
+		c.Cache.Invalidate(m)
+	}
+}
+
+// MemoryCache keeps entries in memory. It is safe for concurrent use.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]map[string]memoryEntry
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	payload string
+	expires time.Time
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]map[string]memoryEntry), now: time.Now}
+}
+
+func (m *MemoryCache) Get(method string, key string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[method][key]
+	if !ok {
+		return "", false
+	}
+	if !m.now().Before(entry.expires) {
+		delete(m.entries[method], key)
+		return "", false
+	}
+	return entry.payload, true
+}
+
+func (m *MemoryCache) Set(method string, key string, payload string, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries[method] == nil {
+		m.entries[method] = make(map[string]memoryEntry)
+	}
+	m.entries[method][key] = memoryEntry{payload, m.now().Add(ttl)}
+}
+
+func (m *MemoryCache) Invalidate(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, method)
+}
+
+// DiskCache keeps entries as files in a directory per method, so they
+// survive between runs of a command. Errors are treated as misses.
+type DiskCache struct {
+	Dir string
+	now func() time.Time
+}
+
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{Dir: dir, now: time.Now}
+}
+
+func (d *DiskCache) path(method string, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.Dir, method, hex.EncodeToString(sum[:]))
+}
+
+// Get reads an entry, which is the expiry in Unix nanoseconds on the first
+// line followed by the payload.
+func (d *DiskCache) Get(method string, key string) (string, bool) {
+	path := d.path(method, key)
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", false
+	}
+	expires, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+	if err != nil || d.now().UnixNano() >= expires {
+		os.Remove(path)
+		return "", false
+	}
+	var payload strings.Builder
+	if _, err := r.WriteTo(&payload); err != nil {
+		return "", false
+	}
+	return payload.String(), true
+}
+
+func (d *DiskCache) Set(method string, key string, payload string, ttl time.Duration) {
+	path := d.path(method, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	// Write to a temporary file first so readers never see half an entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.WriteString(strconv.FormatInt(d.now().Add(ttl).UnixNano(), 10) + "\n" + payload)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(tmp.Name(), path) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+func (d *DiskCache) Invalidate(method string) {
+	os.RemoveAll(filepath.Join(d.Dir, method))
+}
@@ -0,0 +1,123 @@
+package flickr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClientCache(t *testing.T) {
+	calls := make(map[string]int)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		method := r.Form.Get("method")
+		calls[method]++
+		switch method {
+		case "flickr.photosets.getList":
+			fmt.Fprintf(w, `<rsp stat="ok"><photosets page="1" pages="1" perpage="1" total="1"><photoset id="%d"><title>Trip</title></photoset></photosets></rsp>`, calls[method])
+		default:
+			fmt.Fprint(w, `<rsp stat="ok"></rsp>`)
+		}
+	})
+	cache := NewMemoryCache()
+	now := time.Unix(1000, 0)
+	cache.now = func() time.Time { return now }
+	client.Cache = cache
+	ctx := context.Background()
+
+	getList := func() string {
+		sets, err := client.Photosets().GetList(ctx, "", nil)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		return sets.Photoset[0].Id
+	}
+	if getList() != "1" || getList() != "1" || calls["flickr.photosets.getList"] != 1 {
+		t.Errorf("second getList was not cached: %v", calls)
+	}
+	if _, err := client.Photosets().GetList(ctx, "someone", nil); err != nil || calls["flickr.photosets.getList"] != 2 {
+		t.Errorf("other args hit the cache: %v, %v", err, calls)
+	}
+
+	if err := client.Photosets().EditMeta(ctx, "1", "Trip", ""); err != nil {
+		t.Fatal(err)
+	}
+	if getList() != "3" {
+		t.Error("write did not invalidate getList")
+	}
+
+	if err := client.Collections().Delete(ctx, "1", false); err != nil {
+		t.Fatal(err)
+	}
+	if getList() != "3" {
+		t.Error("unrelated write invalidated getList")
+	}
+
+	now = now.Add(DefaultCacheTTLs["flickr.photosets.getList"])
+	if getList() != "4" {
+		t.Error("expired entry was served")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	a := map[string]string{"method": "m", "user_id": "1", "oauth_token": "t", "oauth_nonce": "1", "oauth_signature": "x"}
+	b := map[string]string{"method": "m", "oauth_token": "t", "user_id": "1", "oauth_nonce": "2", "oauth_timestamp": "9", "oauth_signature_method": "HMAC-SHA1", "oauth_version": "1.0"}
+	if cacheKey(a) != cacheKey(b) || cacheKey(a) != "method=m&oauth_token=t&user_id=1" {
+		t.Errorf("got %q and %q", cacheKey(a), cacheKey(b))
+	}
+}
+
+func TestCacheAfterRetry(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `<rsp stat="ok"><photosets page="1" pages="1" perpage="1" total="0"></photosets></rsp>`)
+	})
+	client.Cache = NewMemoryCache()
+	client.RetryPolicy = &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Photosets().GetList(ctx, "", nil); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("result fetched on a retry was not served from the cache: %d calls", calls)
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	cache := NewDiskCache(t.TempDir())
+	now := time.Unix(1000, 0)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("flickr.photos.getSizes", "a", "<sizes>\n</sizes>", time.Minute)
+	cache.Set("flickr.photos.getSizes", "b", "b", time.Minute)
+	cache.Set("flickr.photos.getInfo", "a", "info", time.Minute)
+	if payload, ok := cache.Get("flickr.photos.getSizes", "a"); !ok || payload != "<sizes>\n</sizes>" {
+		t.Errorf("got %q, %v", payload, ok)
+	}
+	if _, ok := cache.Get("flickr.photos.getSizes", "c"); ok {
+		t.Error("hit for a missing key")
+	}
+
+	cache.Invalidate("flickr.photos.getSizes")
+	if _, ok := cache.Get("flickr.photos.getSizes", "b"); ok {
+		t.Error("hit after invalidation")
+	}
+	if _, ok := cache.Get("flickr.photos.getInfo", "a"); !ok {
+		t.Error("invalidation dropped another method")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := cache.Get("flickr.photos.getInfo", "a"); ok {
+		t.Error("hit for an expired entry")
+	}
+}
@@ -0,0 +1,144 @@
+package flickr
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Client holds everything shared by the requests made on behalf of one
+// Flickr account: credentials, endpoints, the HTTP client and default args.
+type Client struct {
+	Auth            map[string]string
+	Secret          string
+	APIEndpoint     string
+	UploadEndpoint  string
+	ReplaceEndpoint string
+	// OAuth endpoints used by the authorization flow.
+	RequestTokenEndpoint string
+	AuthorizeEndpoint    string
+	AccessTokenEndpoint  string
+	HTTPClient           *http.Client
+	// Signer signs every request; nil means DefaultSigner.
+	Signer *Signer
+	// DefaultArgs are added to every request unless the request sets them itself.
+	DefaultArgs map[string]string
+	// Format is the response format of new requests, FormatXML unless set.
+	Format string
+	// RetryPolicy retries the calls made by the typed services; nil means
+	// no retry.
+	RetryPolicy *RetryPolicy
+	// UploadLimits are checked before uploading; nil means DefaultUploadLimits.
+	UploadLimits *UploadLimits
+	// RateLimiter, if set, keeps all requests of the client within its
+	// budget. Share it between clients using the same API key.
+	RateLimiter *RateLimiter
+	// Cache, if set, keeps the results of the read-only methods in
+	// CacheTTLs, or DefaultCacheTTLs when nil. Writes made through the
+	// client invalidate what they affect.
+	Cache     Cache
+	CacheTTLs map[string]time.Duration
+	// Middleware hooks into every request sent, see Middleware.
+	Middleware []Middleware
+	// Logger receives the diagnostics of the client: calls at debug level,
+	// failures and retries at warn level. Nil means no logging.
+	Logger *slog.Logger
+}
+
+func NewClient(auth map[string]string, secret string) *Client {
+	a := make(map[string]string)
+	for k, v := range auth {
+		a[k] = v
+	}
+	return &Client{
+		Auth:                 a,
+		Secret:               secret,
+		APIEndpoint:          apiEndpoint,
+		UploadEndpoint:       uploadEndpoint,
+		ReplaceEndpoint:      replaceEndpoint,
+		RequestTokenEndpoint: requestTokenEndpoint,
+		AuthorizeEndpoint:    authorizeEndpoint,
+		AccessTokenEndpoint:  accessTokenEndpoint,
+		HTTPClient:           http.DefaultClient,
+		DefaultArgs:          make(map[string]string),
+	}
+}
+
+func (c *Client) NewRequest(httpMethod string, additionalArgs map[string]string) *Request {
+	args := make(map[string]string)
+	for k, v := range c.DefaultArgs {
+		args[k] = v
+	}
+	for k, v := range additionalArgs {
+		args[k] = v
+	}
+	request := newRequest(httpMethod, c.Auth, args, c.Secret)
+	request.client = c
+	if _, ok := additionalArgs["format"]; !ok && c.Format != "" {
+		request.SetFormat(c.Format)
+	}
+	return request
+}
+
+// call executes an API method and decodes the result found under key into v,
+// which may be nil when only success matters.
+func (c *Client) call(ctx context.Context, httpMethod string, method string, args map[string]string, key string, v interface{}) error {
+	request := c.NewRequest(httpMethod, args)
+	request.args["method"] = method
+	payload, err := c.execute(ctx, request)
+	if err != nil || v == nil {
+		return err
+	}
+	return Unmarshal(request.Format(), payload, key, v)
+}
+
+// execute runs a request of the typed services, retrying as configured.
+func (c *Client) execute(ctx context.Context, request *Request) (string, error) {
+	if c.RetryPolicy != nil {
+		return request.ExecuteWithPolicyContext(ctx, c.RetryPolicy)
+	}
+	return request.ExecuteContext(ctx)
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *Client) signer() *Signer {
+	if c.Signer != nil {
+		return c.Signer
+	}
+	return DefaultSigner
+}
+
+func (c *Client) uploadLimits() *UploadLimits {
+	if c.UploadLimits != nil {
+		return c.UploadLimits
+	}
+	return DefaultUploadLimits
+}
+
+func (c *Client) apiEndpoint() string {
+	if c.APIEndpoint != "" {
+		return c.APIEndpoint
+	}
+	return apiEndpoint
+}
+
+func (c *Client) uploadEndpoint() string {
+	if c.UploadEndpoint != "" {
+		return c.UploadEndpoint
+	}
+	return uploadEndpoint
+}
+
+func (c *Client) replaceEndpoint() string {
+	if c.ReplaceEndpoint != "" {
+		return c.ReplaceEndpoint
+	}
+	return replaceEndpoint
+}
@@ -0,0 +1,228 @@
+package flickr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type Collections struct {
+	Collection []Collection `xml:"collection" json:"collection"`
+}
+
+// Collection is a node of the collection tree. A collection holds either
+// nested collections or sets, never both.
+type Collection struct {
+	Id          string          `xml:"id,attr" json:"id"`
+	Title       string          `xml:"title,attr" json:"title"`
+	Description string          `xml:"description,attr" json:"description"`
+	IconLarge   string          `xml:"iconlarge,attr" json:"iconlarge"`
+	IconSmall   string          `xml:"iconsmall,attr" json:"iconsmall"`
+	Url         string          `xml:"url,attr" json:"url"`
+	Collection  []Collection    `xml:"collection" json:"collection"`
+	Set         []CollectionSet `xml:"set" json:"set"`
+}
+
+type CollectionSet struct {
+	Id          string `xml:"id,attr" json:"id"`
+	Title       string `xml:"title,attr" json:"title"`
+	Description string `xml:"description,attr" json:"description"`
+}
+
+// Find looks up a collection by the slash-separated titles leading to it,
+// e.g. "Travel/2019/Japan". It returns nil when there is no such collection.
+func (c *Collections) Find(path string) *Collection {
+	return findCollection(c.Collection, splitPath(path))
+}
+
+// Find looks up a collection below c by a path relative to it.
+func (c *Collection) Find(path string) *Collection {
+	return findCollection(c.Collection, splitPath(path))
+}
+
+// HasSet reports whether the photoset is a direct member of c.
+func (c *Collection) HasSet(photosetId string) bool {
+	for _, s := range c.Set {
+		if s.Id == photosetId {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk calls fn for every collection in the tree, parents before children,
+// with the path leading to it.
+func (c *Collections) Walk(fn func(path string, collection *Collection)) {
+	walkCollections(c.Collection, "", fn)
+}
+
+func walkCollections(collections []Collection, prefix string, fn func(string, *Collection)) {
+	for i := range collections {
+		path := prefix + collections[i].Title
+		fn(path, &collections[i])
+		walkCollections(collections[i].Collection, path+"/", fn)
+	}
+}
+
+func findCollection(collections []Collection, path []string) *Collection {
+	if len(path) == 0 {
+		return nil
+	}
+	for i := range collections {
+		if collections[i].Title != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return &collections[i]
+		}
+		if found := findCollection(collections[i].Collection, path[1:]); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func splitPath(path string) []string {
+	var parts []string
+	for _, p := range strings.Split(path, "/") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// CollectionsService wraps the flickr.collections.* methods.
+type CollectionsService struct {
+	client *Client
+}
+
+func (c *Client) Collections() *CollectionsService {
+	return &CollectionsService{c}
+}
+
+// GetTree returns the tree below collectionId, or the whole tree when empty,
+// of userId, or of the calling user when empty.
+func (s *CollectionsService) GetTree(ctx context.Context, collectionId string, userId string) (*Collections, error) {
+	args := make(map[string]string)
+	if collectionId != "" {
+		args["collection_id"] = collectionId
+	}
+	if userId != "" {
+		args["user_id"] = userId
+	}
+	var collections Collections
+	if err := s.client.call(ctx, http.MethodGet, "flickr.collections.getTree", args, "collections", &collections); err != nil {
+		return nil, err
+	}
+	return &collections, nil
+}
+
+// Create makes a new collection, at the top level when parentId is empty.
+func (s *CollectionsService) Create(ctx context.Context, title string, description string, parentId string) (*Collection, error) {
+	args := map[string]string{"title": title}
+	if description != "" {
+		args["description"] = description
+	}
+	if parentId != "" {
+		args["parent_id"] = parentId
+	}
+	var collection Collection
+	if err := s.client.call(ctx, http.MethodPost, "flickr.collections.create", args, "collection", &collection); err != nil {
+		return nil, err
+	}
+	collection.Title = title
+	collection.Description = description
+	return &collection, nil
+}
+
+func (s *CollectionsService) EditMeta(ctx context.Context, collectionId string, title string, description string) error {
+	args := map[string]string{
+		"collection_id": collectionId,
+		"title":         title,
+		"description":   description,
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.collections.editMeta", args, "", nil)
+}
+
+// EditSets replaces the sets of a collection, in the given order.
+func (s *CollectionsService) EditSets(ctx context.Context, collectionId string, photosetIds []string) error {
+	args := map[string]string{
+		"collection_id": collectionId,
+		"photoset_ids":  strings.Join(photosetIds, ","),
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.collections.editSets", args, "", nil)
+}
+
+func (s *CollectionsService) AddSet(ctx context.Context, collectionId string, photosetId string) error {
+	args := map[string]string{
+		"collection_id": collectionId,
+		"photoset_id":   photosetId,
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.collections.addSet", args, "", nil)
+}
+
+func (s *CollectionsService) RemoveSet(ctx context.Context, collectionId string, photosetId string) error {
+	args := map[string]string{
+		"collection_id": collectionId,
+		"photoset_id":   photosetId,
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.collections.removeSet", args, "", nil)
+}
+
+// Delete removes a collection. Unless recursive, its children move up to
+// its parent.
+func (s *CollectionsService) Delete(ctx context.Context, collectionId string, recursive bool) error {
+	args := map[string]string{"collection_id": collectionId}
+	if recursive {
+		args["recursive"] = "1"
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.collections.delete", args, "", nil)
+}
+
+// MoveCollection moves a collection under parentId, or to the top level
+// when parentId is empty.
+func (s *CollectionsService) MoveCollection(ctx context.Context, collectionId string, parentId string) error {
+	args := map[string]string{"collection_id": collectionId}
+	if parentId != "" {
+		args["parent_collection_id"] = parentId
+	} else {
+		args["parent_collection_id"] = "0"
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.collections.moveCollection", args, "", nil)
+}
+
+// CreatePath finds the collection at path, creating whatever part of the
+// path does not exist yet.
+func (s *CollectionsService) CreatePath(ctx context.Context, path string) (*Collection, error) {
+	tree, err := s.GetTree(ctx, "", "")
+	if err != nil {
+		return nil, err
+	}
+	var parent *Collection
+	children := tree.Collection
+	for _, title := range splitPath(path) {
+		var next *Collection
+		for i := range children {
+			if children[i].Title == title {
+				next = &children[i]
+				break
+			}
+		}
+		if next == nil {
+			parentId := ""
+			if parent != nil {
+				parentId = parent.Id
+			}
+			if next, err = s.Create(ctx, title, "", parentId); err != nil {
+				return nil, err
+			}
+		}
+		parent, children = next, next.Collection
+	}
+	if parent == nil {
+		return nil, errors.New("Empty collection path")
+	}
+	return parent, nil
+}
@@ -0,0 +1,62 @@
+package flickr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+const collectionTree = `<rsp stat="ok"><collections>
+<collection id="1-1" title="Travel" description="Trips" iconlarge="http://example.com/l.jpg" iconsmall="http://example.com/s.jpg">
+	<collection id="1-2" title="2019">
+		<set id="500" title="Japan" description="" />
+	</collection>
+</collection>
+<collection id="1-3" title="Family" />
+</collections></rsp>`
+
+func TestCollectionsTree(t *testing.T) {
+	created := map[string]string{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.Form.Get("method") {
+		case "flickr.collections.getTree":
+			fmt.Fprint(w, collectionTree)
+		case "flickr.collections.create":
+			created[r.Form.Get("title")] = r.Form.Get("parent_id")
+			fmt.Fprintf(w, `<rsp stat="ok"><collection id="new-%s" url="http://example.com/c/" /></rsp>`, r.Form.Get("title"))
+		default:
+			t.Errorf("unexpected method %q", r.Form.Get("method"))
+		}
+	})
+	ctx := context.Background()
+
+	tree, err := client.Collections().GetTree(ctx, "", "")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	japan := tree.Find("Travel/2019")
+	if japan == nil || japan.Id != "1-2" || !japan.HasSet("500") || japan.Set[0].Title != "Japan" {
+		t.Errorf("unexpected collection %+v", japan)
+	}
+	if travel := tree.Find("/Travel/"); travel == nil || travel.IconSmall != "http://example.com/s.jpg" || travel.Find("2019") != japan {
+		t.Errorf("unexpected collection %+v", travel)
+	}
+	if tree.Find("Travel/2020") != nil || tree.Find("") != nil {
+		t.Error("found a collection that does not exist")
+	}
+	var paths []string
+	tree.Walk(func(path string, c *Collection) { paths = append(paths, path) })
+	if fmt.Sprint(paths) != "[Travel Travel/2019 Family]" {
+		t.Errorf("unexpected walk %v", paths)
+	}
+
+	collection, err := client.Collections().CreatePath(ctx, "Travel/2020/Spain")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if collection.Id != "new-Spain" || created["2020"] != "1-1" || created["Spain"] != "new-2020" || len(created) != 2 {
+		t.Errorf("unexpected creation %+v %v", collection, created)
+	}
+}
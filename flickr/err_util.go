@@ -1,12 +1,104 @@
 package flickr
 
-import "fmt"
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
 
+// Sentinel errors to compare against with errors.Is.
+const (
+	ErrInvalidSignature   = Error("invalid signature")
+	ErrNotFound           = Error("not found")
+	ErrServiceUnavailable = Error("service currently unavailable")
+	ErrAlreadyInSet       = Error("already in set")
+	ErrNotImage           = Error("not an image or video")
+	ErrTooLarge           = Error("file too large")
+	ErrTooLong            = Error("video too long")
+	ErrRateLimited        = Error("rate limit exceeded")
+)
+
+// FlickrError is a failure reported by the Flickr API.
+type FlickrError struct {
+	Code       int
+	Message    string
+	Method     string
+	HTTPStatus int
+	// RetryAfter is how long the server asked to wait before trying again.
+	RetryAfter time.Duration
+}
+
+func (e *FlickrError) Error() string {
+	return strconv.Itoa(e.Code) + ": " + e.Message
+}
+
+// Is maps Flickr error codes, which are only unique per method, onto the
+// sentinel errors.
+func (e *FlickrError) Is(target error) bool {
+	switch target {
+	case ErrInvalidSignature:
+		return e.Code == 96
+	case ErrServiceUnavailable:
+		return e.Code == 105
+	case ErrNotFound:
+		for _, code := range notFoundCodes[e.Method] {
+			if e.Code == code {
+				return true
+			}
+		}
+		return false
+	case ErrAlreadyInSet:
+		return e.Method == "flickr.photosets.addPhoto" && e.Code == 3 ||
+			e.Method == "flickr.collections.addSet" && e.Code == 4
+	}
+	return false
+}
+
+// notFoundCodes are the documented codes per method for a photo, photoset
+// or collection that does not exist.
+var notFoundCodes = map[string][]int{
+	"flickr.photos.getInfo":            {1},
+	"flickr.photos.getSizes":           {1},
+	"flickr.photos.getExif":            {1},
+	"flickr.photos.delete":             {1},
+	"flickr.photos.setMeta":            {1},
+	"flickr.photos.setTags":            {1},
+	"flickr.photos.addTags":            {1},
+	"flickr.photos.removeTag":          {1},
+	"flickr.photos.setDates":           {1},
+	"flickr.photos.setPerms":           {1},
+	"flickr.photos.setSafetyLevel":     {1},
+	"flickr.photos.setContentType":     {1},
+	"flickr.photosets.getInfo":         {1},
+	"flickr.photosets.getPhotos":       {1},
+	"flickr.photosets.delete":          {1},
+	"flickr.photosets.editMeta":        {1},
+	"flickr.photosets.editPhotos":      {1, 2},
+	"flickr.photosets.addPhoto":        {1, 2},
+	"flickr.photosets.removePhoto":     {1, 2},
+	"flickr.photosets.removePhotos":    {1, 2},
+	"flickr.photosets.reorderPhotos":   {1, 2},
+	"flickr.photosets.setPrimaryPhoto": {1, 2},
+	"flickr.collections.getInfo":       {1},
+}
+
+// IsFlickrError reports whether err is an API error with the given code.
+func IsFlickrError(err error, code int) bool {
+	var flickrErr *FlickrError
+	return errors.As(err, &flickrErr) && flickrErr.Code == code
+}
+
+// CheckErr logs err with msg through the default slog logger and panics,
+// for the commands.
 func CheckErr(err error, msg ...string) {
 	if err != nil {
-		for m := range msg {
-			fmt.Println(m)
+		message := "Failed"
+		if len(msg) > 0 {
+			message = strings.Join(msg, " ")
 		}
+		slog.Error(message, "error", redactString(err.Error()))
 		panic(err)
 	}
 }
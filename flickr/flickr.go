@@ -2,15 +2,16 @@ package flickr
 
 import (
 	"bytes"
-	"crypto/hmac"
-	"crypto/sha1"
-	"encoding/base64"
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -26,45 +27,28 @@ const (
 	apiEndpoint     = "https://api.flickr.com/services/rest"
 	uploadEndpoint  = "https://up.flickr.com/services/upload"
 	replaceEndpoint = "https://up.flickr.com/services/replace"
-)
-
-type Photo struct {
-	Id             string `xml:"id,attr"`
-	Title          string `xml:"title,attr"`
-	UrlO           string `xml:"url_o,attr"`
-	OriginalFormat string `xml:"originalformat,attr"`
-}
-
-type Photoset struct {
-	Id    string  `xml:"id,attr"`
-	Title string  `xml:"title"`
-	Photo []Photo `xml:"photo"`
-	Pages int     `xml:"pages,attr"`
-}
-
-type Photosets struct {
-	Photoset []Photoset `xml:"photoset"`
-}
 
-type Collections struct {
-	Collection []Collection `xml:"collection"`
-}
-
-type Collection struct {
-	Id    string `xml:"id,attr"`
-	Title string `xml:"title,attr"`
-}
+	// uploadMethod and replaceMethod stand in for the API method name in
+	// upload and replace errors.
+	uploadMethod  = "upload"
+	replaceMethod = "replace"
+)
 
 type Request struct {
 	httpMethod string
 	args       map[string]string
 	secret     string
+	client     *Client
+	progress   ProgressFunc
 }
 
 type Response struct {
-	Status  string         `xml:"stat,attr"`
-	Error   *ResponseError `xml:"err"`
-	Payload string         `xml:",innerxml"`
+	Status     string         `xml:"stat,attr"`
+	Error      *ResponseError `xml:"err"`
+	Payload    string         `xml:",innerxml"`
+	HTTPStatus int            `xml:"-"`
+	Format     string         `xml:"-"`
+	RetryAfter time.Duration  `xml:"-"`
 }
 
 type ResponseError struct {
@@ -85,11 +69,11 @@ func (e Error) Error() string {
 }
 
 func NewRequest(httpMethod string, auth map[string]string, additionalArgs map[string]string, secret string) *Request {
+	return NewClient(auth, secret).NewRequest(httpMethod, additionalArgs)
+}
+
+func newRequest(httpMethod string, auth map[string]string, additionalArgs map[string]string, secret string) *Request {
 	args := make(map[string]string)
-	epoch := strconv.FormatInt(time.Now().Unix(), 10)
-	args["oauth_nonce"] = epoch
-	args["oauth_timestamp"] = epoch
-	args["oauth_signature_method"] = "HMAC-SHA1"
 	for k, v := range auth {
 		args[k] = v
 	}
@@ -98,86 +82,88 @@ func NewRequest(httpMethod string, auth map[string]string, additionalArgs map[st
 			args[k] = v
 		}
 	}
-	request := Request{httpMethod, args, secret}
+	request := Request{httpMethod: httpMethod, args: args, secret: secret}
 	return &request
 }
 
-func (request *Request) sign(requestUrl string) {
-	args := request.args
-	delete(args, "oauth_signature")
-
-	sorted_keys := make([]string, len(args))
-
-	// Sort array keys
-	i := 0
-	for k := range args {
-		sorted_keys[i] = k
-		i++
-	}
-	sort.Strings(sorted_keys)
-
-	// Build out ordered key-value string prefixed by secret
-	base := request.httpMethod + "&" + url.QueryEscape(requestUrl) + "&"
-	var params string
-	for _, key := range sorted_keys {
-		value := url.QueryEscape(args[key])
-		params += fmt.Sprintf("%s=%s&", key, strings.Replace(value, "+", `%20`, -1))
-	}
-	params = params[:len(params)-1]
-	base += url.QueryEscape(params)
-
-	// Have the full string, now hash
-	hash := hmac.New(sha1.New, []byte(request.secret))
-	hash.Write([]byte(base))
-	sha := base64.StdEncoding.EncodeToString(hash.Sum(nil))
-
-	// Add oauth_signature as one of the args
-	args["oauth_signature"] = fmt.Sprintf("%s", sha)
+func (request *Request) sign(requestUrl string) error {
+	return request.getClient().signer().Sign(request.httpMethod, requestUrl, request.args, request.secret)
 }
 
-func (request *Request) composeGetUrl() string {
-	s := apiEndpoint + "?" + encodeQuery(request.args)
-	return s
+func (request *Request) getClient() *Client {
+	if request.client == nil {
+		request.client = NewClient(nil, request.secret)
+	}
+	return request.client
 }
 
 func (request *Request) Execute() (res string, ret error) {
-	var call_err error
-	var response *Response
+	return request.ExecuteContext(context.Background())
+}
 
+func (request *Request) ExecuteContext(ctx context.Context) (res string, ret error) {
+	client := request.getClient()
+	endpoint := client.apiEndpoint()
+	format := request.Format()
+	if format == FormatJSON {
+		request.args["nojsoncallback"] = "1"
+	}
+	method := request.args["method"]
+	ttl := client.cacheTTL(request)
+	key := ""
+	if ttl > 0 {
+		key = cacheKey(request.args)
+		if payload, ok := client.Cache.Get(method, key); ok {
+			client.logger().LogAttrs(ctx, slog.LevelDebug, "Flickr cache hit", slog.String("method", method))
+			return payload, nil
+		}
+	}
+	if err := client.RateLimiter.call(ctx); err != nil {
+		return "", err
+	}
+	var build func() (*http.Request, error)
 	switch request.httpMethod {
 	case http.MethodPost:
-		request.sign(apiEndpoint)
-		s := encodeQuery(request.args)
-		postRequest, err := http.NewRequest(http.MethodPost, apiEndpoint, strings.NewReader(s))
-		if err != nil {
-			return "", err
+		build = func() (*http.Request, error) {
+			postRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(encodeQuery(request.args)))
+			if err != nil {
+				return nil, err
+			}
+			postRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded; param=value")
+			return postRequest, nil
 		}
-		postRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded; param=value")
-		response, call_err = sendPost(postRequest)
 	case http.MethodGet:
-		request.sign(apiEndpoint)
-		s := request.composeGetUrl()
-
-		var res *http.Response
-		res, call_err = http.Get(s)
-
-		body, _ := ioutil.ReadAll(res.Body)
-		res.Body.Close()
-		if err := xml.Unmarshal(body, &response); err != nil {
-			return "", err
+		build = func() (*http.Request, error) {
+			return http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+encodeQuery(request.args), nil)
 		}
 	default:
 		return "", errors.New("Unsupported HTTP method")
 	}
-	if err := checkError(call_err, response); err != nil {
+	response, err := client.roundTrip(ctx, request, method, endpoint, format, build)
+	if err != nil {
 		return "", err
 	}
+	if ttl > 0 {
+		client.Cache.Set(method, key, response.Payload, ttl)
+	} else if request.httpMethod == http.MethodPost {
+		client.invalidate(method)
+	}
 	return response.Payload, nil
 }
 
-func checkError(err error, response *Response) error {
+func checkError(err error, response *Response, method string) error {
 	if response != nil && response.Error != nil {
-		return errors.New(response.Error.Code + ": " + response.Error.Message)
+		code, _ := strconv.Atoi(response.Error.Code)
+		return &FlickrError{
+			Code:       code,
+			Message:    response.Error.Message,
+			Method:     method,
+			HTTPStatus: response.HTTPStatus,
+			RetryAfter: response.RetryAfter,
+		}
+	}
+	if flickrErr, ok := err.(*FlickrError); ok && flickrErr.Method == "" {
+		flickrErr.Method = method
 	}
 	return err
 }
@@ -190,111 +176,235 @@ func encodeQuery(args map[string]string) string {
 	return strings.TrimSuffix(b.String(), "&")
 }
 
-func (request *Request) buildPost(url_ string, photopath string, filetype string) (*http.Request, error) {
-	realUrl, _ := url.Parse(url_)
-
-	f, err := os.Open(photopath)
-	if err != nil {
+// buildPost streams the request args and the content of r as a multipart
+// body; size is the length of the content, or -1 when unknown. Everything
+// but the content is rendered up front so the Content-Length is exact, and
+// a failing or short read aborts the request instead of truncating it.
+func (request *Request) buildPost(ctx context.Context, url_ string, name string, r io.Reader, contentType string, size int64) (*http.Request, error) {
+	var header, footer bytes.Buffer
+	dst := &switchWriter{&header}
+	mw := multipart.NewWriter(dst)
+	keys := make([]string, 0, len(request.args))
+	for k := range request.args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := mw.WriteField(k, request.args[k]); err != nil {
+			return nil, err
+		}
+	}
+	partHeader := make(textproto.MIMEHeader)
+	partHeader.Set("Content-Disposition", `form-data; name="photo"; filename="`+escapeFilename(name)+`"`)
+	partHeader.Set("Content-Type", contentType)
+	if _, err := mw.CreatePart(partHeader); err != nil {
 		return nil, err
 	}
-
-	stat, err := f.Stat()
-	if err != nil {
+	dst.w = &footer
+	if err := mw.Close(); err != nil {
 		return nil, err
 	}
-	f_size := stat.Size()
 
-	boundary, end := "----###---###--flickr-go-rules", "\r\n"
-
-	// Build out all of POST body sans file
-	header := bytes.NewBuffer(nil)
-	for k, v := range request.args {
-		header.WriteString("--" + boundary + end)
-		header.WriteString("Content-Disposition: form-data; name=\"" + k + "\"" + end + end)
-		header.WriteString(v + end)
+	bodyLen := int64(-1)
+	if size >= 0 {
+		bodyLen = int64(header.Len()) + size + int64(footer.Len())
 	}
-	header.WriteString("--" + boundary + end)
-	header.WriteString("Content-Disposition: form-data; name=\"photo\"; filename=\"" + filepath.Base(photopath) + "\"" + end)
-	header.WriteString("Content-Type: " + filetype + end + end)
-
-	footer := bytes.NewBufferString(end + "--" + boundary + "--" + end)
 
-	bodyLen := int64(header.Len()) + int64(footer.Len()) + f_size
-
-	r, w := io.Pipe()
+	pr, pw := io.Pipe()
 	go func() {
-		pieces := []io.Reader{header, f, footer}
-
-		for _, k := range pieces {
-			_, err = io.Copy(w, k)
-			if err != nil {
-				w.CloseWithError(nil)
-				return
-			}
+		if _, err := header.WriteTo(pw); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		n, err := io.Copy(pw, r)
+		if err == nil && size >= 0 && n != size {
+			err = fmt.Errorf("%s: read %d bytes, expected %d", name, n, size)
 		}
-		f.Close()
-		w.Close()
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := footer.WriteTo(pw); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.Close()
 	}()
 
-	httpHeader := make(http.Header)
-	httpHeader.Add("Content-Type", "multipart/form-data; boundary="+boundary)
-
-	postRequest := &http.Request{
-		Method:        http.MethodPost,
-		URL:           realUrl,
-		Header:        httpHeader,
-		Body:          r,
-		ContentLength: bodyLen,
+	postRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, url_, pr)
+	if err != nil {
+		pr.Close()
+		return nil, err
 	}
+	postRequest.Header.Set("Content-Type", mw.FormDataContentType())
+	postRequest.ContentLength = bodyLen
 	return postRequest, nil
 }
 
+// switchWriter lets buildPost split what the multipart writer writes into
+// the parts before and after the file content.
+type switchWriter struct {
+	w io.Writer
+}
+
+func (s *switchWriter) Write(b []byte) (int, error) {
+	return s.w.Write(b)
+}
+
+// filenameEscaper quotes a file name like multipart.CreateFormFile does
+// and, like browsers, percent-encodes line breaks that would end the header.
+var filenameEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "%0D", "\n", "%0A")
+
+func escapeFilename(name string) string {
+	return filenameEscaper.Replace(name)
+}
+
 func (request *Request) Upload(photopath string) (photoId string, err error) {
-	fileType, err := filetype.MatchFile(photopath)
-	checkError(err, nil)
-	if !IsImage(fileType) {
-		return "", errors.New(photopath + " is not an image.")
-	}
+	return request.UploadContext(context.Background(), photopath)
+}
 
-	request.httpMethod = http.MethodPost
-	request.sign(uploadEndpoint)
-	postRequest, err := request.buildPost(uploadEndpoint, photopath, fileType.MIME.Value)
+func (request *Request) UploadContext(ctx context.Context, photopath string) (photoId string, err error) {
+	response, err := request.postFile(ctx, request.getClient().uploadEndpoint(), photopath, uploadMethod)
 	if err != nil {
 		return "", err
 	}
-	response, err := sendPost(postRequest)
-	if err := checkError(err, response); err != nil {
-		return "", err
-	}
 	err = xml.Unmarshal([]byte(response.Payload), &photoId)
 	return photoId, err
 }
 
-//TODO Not completed yet
-func (request *Request) replace(filename string, filetype string) (response *Response, err error) {
-	postRequest, err := request.buildPost(replaceEndpoint, filename, filetype)
+// ReplaceResult is the answer to a replace. Secret and OriginalSecret change
+// with the new file, so cached photo URLs have to be rebuilt. An async replace
+// only gets a TicketId.
+type ReplaceResult struct {
+	PhotoId        string
+	Secret         string
+	OriginalSecret string
+	TicketId       string
+}
+
+// Replace swaps the file of an existing photo for photopath. The photo keeps
+// its id, title, comments, sets and stats. After SetAsync(true) only a ticket
+// comes back instead of waiting for Flickr to process the file.
+func (request *Request) Replace(photoId string, photopath string) (*ReplaceResult, error) {
+	return request.ReplaceContext(context.Background(), photoId, photopath)
+}
+
+func (request *Request) ReplaceContext(ctx context.Context, photoId string, photopath string) (*ReplaceResult, error) {
+	if photoId == "" {
+		return nil, errors.New("Missing photo id")
+	}
+	request.args["photo_id"] = photoId
+	response, err := request.postFile(ctx, request.getClient().replaceEndpoint(), photopath, replaceMethod)
 	if err != nil {
 		return nil, err
 	}
-	return sendPost(postRequest)
+	var fields struct {
+		PhotoId *struct {
+			Id             string `xml:",chardata"`
+			Secret         string `xml:"secret,attr"`
+			OriginalSecret string `xml:"originalsecret,attr"`
+		} `xml:"photoid"`
+		TicketId string `xml:"ticketid"`
+	}
+	if err := xml.Unmarshal([]byte("<rsp>"+response.Payload+"</rsp>"), &fields); err != nil {
+		return nil, err
+	}
+	result := &ReplaceResult{TicketId: strings.TrimSpace(fields.TicketId)}
+	if fields.PhotoId != nil {
+		result.PhotoId = strings.TrimSpace(fields.PhotoId.Id)
+		result.Secret = fields.PhotoId.Secret
+		result.OriginalSecret = fields.PhotoId.OriginalSecret
+	}
+	if result.PhotoId == "" && result.TicketId == "" {
+		return nil, &FlickrError{Message: "Missing photo id in response", Method: replaceMethod, HTTPStatus: response.HTTPStatus}
+	}
+	return result, nil
 }
 
-func sendPost(postRequest *http.Request) (response *Response, err error) {
-	// Create and use TCP connection (lifted mostly wholesale from http.send)
-	client := http.DefaultClient
-	resp, err := client.Do(postRequest)
+// postFile signs the request against endpoint and posts it together with
+// the image or video at photopath.
+func (request *Request) postFile(ctx context.Context, endpoint string, photopath string, method string) (*Response, error) {
+	fileType, err := filetype.MatchFile(photopath)
+	if err != nil {
+		return nil, err
+	}
+	if err := request.getClient().uploadLimits().check(photopath, fileType); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(photopath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return request.post(ctx, endpoint, filepath.Base(photopath), f, fileType.MIME.Value, stat.Size(), method)
+}
 
+// post signs the request against endpoint and posts it together with the
+// content of r.
+func (request *Request) post(ctx context.Context, endpoint string, name string, r io.Reader, contentType string, size int64, method string) (*Response, error) {
+	client := request.getClient()
+	if err := client.RateLimiter.checkUpload(size); err != nil {
+		return nil, err
+	}
+	if err := client.RateLimiter.call(ctx); err != nil {
+		return nil, err
+	}
+	request.httpMethod = http.MethodPost
+	r = client.RateLimiter.uploadReader(ctx, r)
+	if request.progress != nil {
+		r = newProgressReader(r, name, size, request.progress)
+	}
+	response, err := client.roundTrip(ctx, request, method, endpoint, FormatXML, func() (*http.Request, error) {
+		return request.buildPost(ctx, endpoint, name, r, contentType, size)
+	})
 	if err != nil {
 		return nil, err
 	}
-	rawBody, _ := ioutil.ReadAll(resp.Body)
-	resp.Body.Close()
+	client.invalidate(method)
+	return response, nil
+}
+
+func (c *Client) send(ctx context.Context, call *Call, httpRequest *http.Request, format string) (response *Response, err error) {
+	resp, err := c.httpClient().Do(httpRequest)
 
-	var r Response
-	err = xml.Unmarshal(rawBody, &r)
-	//TODO Temp hack for debug
 	if err != nil {
-		fmt.Println(string(rawBody))
+		return nil, err
+	}
+	rawBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	call.Response, call.Body = resp, rawBody
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		if err := c.Middleware[i].AfterResponse(ctx, call); err != nil {
+			return nil, err
+		}
+	}
+	rawBody = call.Body
+
+	r := Response{HTTPStatus: resp.StatusCode, Format: format}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		r.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	if format == formatOAuth {
+		r.Payload = string(rawBody)
+		if resp.StatusCode != http.StatusOK {
+			err = &FlickrError{Message: resp.Status + ": " + strings.TrimSpace(r.Payload), HTTPStatus: resp.StatusCode, RetryAfter: r.RetryAfter}
+		}
+		return &r, err
+	}
+	if format == FormatJSON {
+		err = unmarshalJSONResponse(rawBody, &r)
+	} else {
+		err = xml.Unmarshal(rawBody, &r)
+	}
+	if (err != nil || r.Error == nil) && resp.StatusCode != http.StatusOK {
+		err = &FlickrError{Message: resp.Status, HTTPStatus: resp.StatusCode, RetryAfter: r.RetryAfter}
 	}
 
 	return &r, err
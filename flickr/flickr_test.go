@@ -1,10 +1,15 @@
 package flickr
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"net/url"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 var auth = map[string]string{
@@ -14,35 +19,291 @@ var auth = map[string]string{
 var secret = ""
 
 func TestSign(t *testing.T) {
-	// t.SkipNow()
 	additionalArgs := map[string]string{
 		"method":           "flickr.photosets.create",
 		"title":            "a b",
 		"primary_photo_id": "40936585950",
 	}
-	request := NewRequest(http.MethodPost, auth, additionalArgs, secret)
-	request.sign("https://api.flickr.com/services/rest")
-	fmt.Println(request.args["oauth_signature"])
-	fmt.Println(url.QueryEscape(request.args["oauth_signature"]))
+	client := NewClient(map[string]string{"oauth_token": "token", "oauth_consumer_key": "key"}, SigningKey("consumer", "token-secret"))
+	client.Signer = fixedSigner(HMACSHA1, 1530000000, "nonce")
+	request := client.NewRequest(http.MethodPost, additionalArgs)
+	if err := request.sign(apiEndpoint); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if got, want := request.args["oauth_signature"], "4sl7GbxFQ3LIeMHdrmVA7auBWHU="; got != want {
+		t.Errorf("got signature %q, want %q", got, want)
+	}
 }
 
 func TestUpload(t *testing.T) {
-	t.SkipNow()
-	request := NewRequest(http.MethodPost, auth, nil, secret)
-	photoid, err := request.Upload("photo.jpg")
+	client := newFixtureClient(t, "upload")
+	ctx := context.Background()
+	photoId, err := client.NewRequest(http.MethodPost, nil).Upload(writeTestJpeg(t))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if photoId != "40936585950" {
+		t.Errorf("unexpected photo id %q", photoId)
+	}
+	photoset, err := client.Photosets().Create(ctx, "test_title", "", photoId)
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
-	fmt.Println(photoid)
+	if photoset.Id != "72157703384622341" || photoset.Title != "test_title" {
+		t.Errorf("unexpected photoset %+v", photoset)
+	}
+	if err := client.Photosets().AddPhoto(ctx, photoset.Id, photoId); !errors.Is(err, ErrAlreadyInSet) {
+		t.Errorf("expected the primary photo to be in the set already, got %+v", err)
+	}
 }
 
-func TestExecute(t *testing.T) {
-	t.SkipNow()
-	additionalArgs := map[string]string{
-		"method":           "flickr.photosets.create",
-		"title":            "test_title",
-		"primary_photo_id": "40936585950",
+func TestDownload(t *testing.T) {
+	client := newFixtureClient(t, "download")
+	ctx := context.Background()
+	photosets := client.Photosets().GetListPaginator(ctx, "", 0)
+	var downloaded []string
+	for photosets.Next() {
+		photos := client.Photosets().GetPhotosPaginator(ctx, photosets.Item().Id, &PhotosetPhotosOptions{
+			Extras: Extras{ExtraURLO, ExtraOriginalFormat, ExtraMedia},
+		}, 0)
+		for photos.Next() {
+			photo := photos.Item()
+			source := photo.UrlO
+			if photo.Media == "video" {
+				sizes, err := client.Photos().GetSizes(ctx, photo.Id)
+				if err != nil {
+					t.Fatalf("%+v", err)
+				}
+				video := sizes.Video()
+				if video == nil {
+					t.Fatalf("no video among %+v", sizes)
+				}
+				source = video.Source
+			}
+			resp, err := client.HTTPClient.Get(source)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil || len(body) == 0 {
+				t.Fatalf("empty download of %s: %v", source, err)
+			}
+			kind, _, _ := strings.Cut(resp.Header.Get("Content-Type"), "/")
+			downloaded = append(downloaded, photo.Title+":"+kind)
+		}
+		if err := photos.Err(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	if err := photosets.Err(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if got := strings.Join(downloaded, " "); got != "beach:image waves:video" {
+		t.Errorf("unexpected downloads %s", got)
+	}
+}
+
+func TestClientEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("method") != "flickr.test.echo" {
+			t.Errorf("unexpected method %q", r.URL.Query().Get("method"))
+		}
+		fmt.Fprint(w, `<rsp stat="ok"><method>flickr.test.echo</method></rsp>`)
+	}))
+	defer server.Close()
+
+	client := NewClient(auth, secret)
+	client.APIEndpoint = server.URL
+	client.HTTPClient = server.Client()
+	response, err := client.NewRequest(http.MethodGet, map[string]string{"method": "flickr.test.echo"}).Execute()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if response != "<method>flickr.test.echo</method>" {
+		t.Errorf("unexpected payload %q", response)
+	}
+}
+
+func TestOAuthFlow(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/request_token", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("oauth_callback") != OutOfBand {
+			t.Errorf("unexpected callback %q", r.URL.Query().Get("oauth_callback"))
+		}
+		fmt.Fprint(w, "oauth_callback_confirmed=true&oauth_token=request-token&oauth_token_secret=request-secret")
+	})
+	mux.HandleFunc("/access_token", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("oauth_verifier") != "verifier" || r.URL.Query().Get("oauth_token") != "request-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "oauth_problem=token_rejected")
+			return
+		}
+		fmt.Fprint(w, "fullname=Jane&oauth_token=access-token&oauth_token_secret=access-secret&user_nsid=1%40N01&username=jane")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(nil, "")
+	var methods []string
+	client.Middleware = []Middleware{MiddlewareFuncs{
+		BeforeSignFunc: func(ctx context.Context, call *Call) error {
+			methods = append(methods, call.Method)
+			return nil
+		},
+	}}
+	client.RequestTokenEndpoint = server.URL + "/request_token"
+	client.AccessTokenEndpoint = server.URL + "/access_token"
+	requestToken, err := client.GetRequestToken("key", "consumer", OutOfBand)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !requestToken.CallbackConfirmed || requestToken.Secret != "request-secret" {
+		t.Errorf("unexpected request token %+v", requestToken)
+	}
+	if _, err := client.GetAccessToken("key", "consumer", requestToken, "wrong"); err == nil {
+		t.Error("expected rejected verifier to fail")
+	}
+	accessToken, err := client.GetAccessToken("key", "consumer", requestToken, "verifier")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	client.SetAccessToken("key", "consumer", accessToken)
+	if client.Auth["oauth_token"] != "access-token" || client.Secret != "consumer&access-secret" || accessToken.UserNsid != "1@N01" {
+		t.Errorf("unexpected client state %+v %+v", client, accessToken)
+	}
+	if got := strings.Join(methods, " "); got != "request_token access_token access_token" {
+		t.Errorf("token requests skipped the middleware: %s", got)
+	}
+}
+
+func TestFlickrError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<rsp stat="fail"><err code="4" msg="Set already in collection" /></rsp>`)
+	})
+	_, err := client.NewRequest(http.MethodPost, map[string]string{"method": "flickr.collections.addSet"}).Execute()
+	var flickrErr *FlickrError
+	if !errors.As(err, &flickrErr) {
+		t.Fatalf("expected a FlickrError, got %+v", err)
+	}
+	if flickrErr.Code != 4 || flickrErr.Method != "flickr.collections.addSet" || flickrErr.HTTPStatus != http.StatusOK {
+		t.Errorf("unexpected error %+v", flickrErr)
+	}
+	if !errors.Is(err, ErrAlreadyInSet) || errors.Is(err, ErrNotFound) {
+		t.Errorf("wrong sentinel match for %v", err)
+	}
+	if errors.Is(&FlickrError{Code: 4, Method: "flickr.photos.getInfo"}, ErrAlreadyInSet) {
+		t.Error("code 4 of another method matched ErrAlreadyInSet")
+	}
+	if errors.Is(&FlickrError{Code: 3, Message: "User not found", Method: "flickr.photos.search"}, ErrNotFound) {
+		t.Error("message text matched ErrNotFound")
+	}
+	if !errors.Is(&FlickrError{Code: 2, Message: "Photo is gone", Method: "flickr.photosets.addPhoto"}, ErrNotFound) {
+		t.Error("code 2 of addPhoto did not match ErrNotFound")
+	}
+	if !errors.Is(fmt.Errorf("retry: %w", &FlickrError{Code: 105}), ErrServiceUnavailable) {
+		t.Error("wrapped code 105 did not match ErrServiceUnavailable")
+	}
+}
+
+func TestExecuteWithRetryContext(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `<rsp stat="fail"><err code="105" msg="Service currently unavailable" /></rsp>`)
+	}))
+	defer server.Close()
+
+	client := NewClient(auth, secret)
+	client.APIEndpoint = server.URL
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.NewRequest(http.MethodGet, nil).ExecuteWithRetryContext(ctx, 3, time.Hour)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %+v", err)
+	}
+	if calls != 1 || time.Since(start) > time.Second {
+		t.Errorf("backoff was not aborted: %d calls in %s", calls, time.Since(start))
+	}
+}
+
+func TestExecuteJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") != FormatJSON || r.URL.Query().Get("nojsoncallback") != "1" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		switch r.URL.Query().Get("method") {
+		case "flickr.photosets.getList":
+			fmt.Fprint(w, `{"photosets":{"photoset":[{"id":"72157","title":{"_content":"Trip"},"pages":"2"}]},"stat":"ok"}`)
+		default:
+			fmt.Fprint(w, `{"stat":"fail","code":1,"message":"Photoset not found"}`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(auth, secret)
+	client.APIEndpoint = server.URL
+	client.Format = FormatJSON
+	request := client.NewRequest(http.MethodGet, map[string]string{"method": "flickr.photosets.getList"})
+	response, err := request.Execute()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var photosets Photosets
+	if err := Unmarshal(request.Format(), response, "photosets", &photosets); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(photosets.Photoset) != 1 || photosets.Photoset[0].Title != "Trip" || photosets.Photoset[0].Pages != 2 {
+		t.Errorf("unexpected photosets %+v", photosets)
+	}
+
+	_, err = client.NewRequest(http.MethodGet, map[string]string{"method": "flickr.photosets.getInfo"}).Execute()
+	if !errors.Is(err, ErrNotFound) || !IsFlickrError(err, 1) {
+		t.Errorf("expected not found error, got %+v", err)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	photopath := writeTestJpeg(t)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/replace" {
+			t.Errorf("replace posted to %s", r.URL.Path)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+			return
+		}
+		if r.FormValue("photo_id") != "123" || r.FormValue("oauth_signature") == "" {
+			t.Errorf("unexpected form %v", r.MultipartForm.Value)
+		}
+		if _, header, err := r.FormFile("photo"); err != nil || header.Filename != "photo.jpg" {
+			t.Errorf("missing photo: %v", err)
+		}
+		if r.FormValue("async") == "1" {
+			fmt.Fprint(w, `<rsp stat="ok"><ticketid>1234-5678</ticketid></rsp>`)
+			return
+		}
+		fmt.Fprint(w, `<rsp stat="ok"><photoid secret="s2" originalsecret="os2">123</photoid></rsp>`)
+	})
+
+	result, err := client.NewRequest(http.MethodPost, nil).Replace("123", photopath)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if *result != (ReplaceResult{PhotoId: "123", Secret: "s2", OriginalSecret: "os2"}) {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	result, err = client.NewRequest(http.MethodPost, map[string]string{"async": "1"}).Replace("123", photopath)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if result.TicketId != "1234-5678" || result.PhotoId != "" {
+		t.Errorf("unexpected async result %+v", result)
+	}
+
+	if _, err := client.NewRequest(http.MethodPost, nil).Replace("", photopath); err == nil {
+		t.Error("expected an error for a missing photo id")
 	}
-	request := NewRequest(http.MethodPost, auth, additionalArgs, secret)
-	fmt.Println(request.Execute())
 }
@@ -0,0 +1,130 @@
+package flickr
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"time"
+)
+
+const (
+	FormatXML  = "rest"
+	FormatJSON = "json"
+)
+
+// SetFormat switches the response format of the request to FormatXML or
+// FormatJSON.
+func (request *Request) SetFormat(format string) {
+	if format == FormatJSON {
+		request.args["format"] = FormatJSON
+		request.args["nojsoncallback"] = "1"
+		return
+	}
+	delete(request.args, "format")
+	delete(request.args, "nojsoncallback")
+}
+
+func (request *Request) Format() string {
+	if request.args["format"] == FormatJSON {
+		return FormatJSON
+	}
+	return FormatXML
+}
+
+// Unmarshal decodes a payload returned by Execute into v. For XML the payload
+// already is the element v describes; for JSON the value is looked up under
+// key, e.g. "photosets".
+func Unmarshal(format string, payload string, key string, v interface{}) error {
+	if format != FormatJSON {
+		return xml.Unmarshal([]byte(payload), v)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil {
+		return err
+	}
+	raw, ok := fields[key]
+	if !ok {
+		return errors.New("No " + key + " in response")
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// unmarshalJSONResponse fills the response envelope from a JSON body. The
+// payload is the whole body since Flickr puts the result next to "stat".
+func unmarshalJSONResponse(body []byte, response *Response) error {
+	var envelope struct {
+		Stat    string `json:"stat"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return err
+	}
+	response.Status = envelope.Stat
+	response.Payload = string(body)
+	if envelope.Stat != "ok" {
+		response.Error = &ResponseError{
+			Code:    strconv.Itoa(envelope.Code),
+			Message: envelope.Message,
+		}
+	}
+	return nil
+}
+
+// flexString decodes plain strings, bare numbers and Flickr's
+// {"_content": "..."} text nodes in JSON, and plain text in XML.
+type flexString string
+
+func (s *flexString) UnmarshalJSON(b []byte) error {
+	if bytes.HasPrefix(b, []byte("{")) {
+		var content struct {
+			Content string `json:"_content"`
+		}
+		if err := json.Unmarshal(b, &content); err != nil {
+			return err
+		}
+		*s = flexString(content.Content)
+		return nil
+	}
+	if !bytes.HasPrefix(b, []byte(`"`)) {
+		// Numbers Flickr did not quote, or null.
+		if string(b) == "null" {
+			b = nil
+		}
+		*s = flexString(b)
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	*s = flexString(str)
+	return nil
+}
+
+// flexInt decodes numbers whether or not Flickr quoted them in JSON. XML
+// attributes decode into it like into an int.
+type flexInt int
+
+func (i *flexInt) UnmarshalJSON(b []byte) error {
+	b = bytes.Trim(b, `"`)
+	if len(b) == 0 || string(b) == "null" {
+		*i = 0
+		return nil
+	}
+	n, err := strconv.Atoi(string(b))
+	*i = flexInt(n)
+	return err
+}
+
+// parseUnix turns Flickr's decimal Unix timestamps into a time.Time; empty
+// or malformed values give the zero time.
+func parseUnix(s string) time.Time {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(n, 0)
+}
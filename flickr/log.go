@@ -0,0 +1,139 @@
+package flickr
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// redactedArgs are never logged in clear.
+var redactedArgs = map[string]bool{"oauth_token": true, "oauth_signature": true, "oauth_verifier": true}
+
+// redactedQuery finds the redacted args in URLs that end up in error
+// messages, such as those of failed GET requests.
+var redactedQuery = regexp.MustCompile(`(oauth_token|oauth_signature|oauth_verifier)=[^&\s"]*`)
+
+const redacted = "REDACTED"
+
+// NewLogger returns a logger writing to w in format "text" or "json" from
+// level "debug", "info", "warn" or "error" on, for the commands to hand to
+// Client.Logger.
+func NewLogger(w io.Writer, format string, level string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, errors.New("Unknown log level " + level)
+	}
+	opts := &slog.HandlerOptions{Level: l}
+	switch format {
+	case "text", "":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, errors.New("Unknown log format " + format)
+}
+
+// discardHandler drops every record; it backs the logger of clients
+// without one.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+var discardLogger = slog.New(discardHandler{})
+
+func (c *Client) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return discardLogger
+}
+
+// newCallId returns a short random id to tell the log lines of concurrent
+// calls apart.
+func newCallId() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// logCall reports a finished round trip: at debug level with its args when
+// it succeeded, at warn level when it failed.
+func (c *Client) logCall(ctx context.Context, call *Call, err error) {
+	logger := c.logger()
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelWarn
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("id", call.Id),
+		slog.String("method", call.Method),
+		slog.String("http_method", call.HTTPMethod),
+		slog.Duration("duration", time.Since(call.Start)),
+	}
+	if call.Response != nil {
+		attrs = append(attrs, slog.Int("status", call.Response.StatusCode))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", redactString(err.Error())))
+		logger.LogAttrs(ctx, level, "Flickr call failed", attrs...)
+		return
+	}
+	attrs = append(attrs, redactArgs(call.Args))
+	logger.LogAttrs(ctx, level, "Flickr call", attrs...)
+}
+
+// redactArgs groups args for logging, hiding the credentials.
+func redactArgs(args map[string]string) slog.Attr {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]any, 0, len(keys))
+	for _, k := range keys {
+		v := args[k]
+		if redactedArgs[k] && v != "" {
+			v = redacted
+		}
+		attrs = append(attrs, slog.String(k, v))
+	}
+	return slog.Group("args", attrs...)
+}
+
+func redactString(s string) string {
+	if !strings.Contains(s, "oauth_") {
+		return s
+	}
+	return redactedQuery.ReplaceAllString(s, "${1}="+redacted)
+}
+
+// retry runs fn under policy, logging every retry on top of the policy's
+// own OnRetry.
+func (request *Request) retry(ctx context.Context, policy *RetryPolicy, fn func() error) error {
+	p := *retryPolicy(policy)
+	onRetry, logger, method := p.OnRetry, request.getClient().logger(), request.args["method"]
+	p.OnRetry = func(attempt int, err error, delay time.Duration) {
+		logger.LogAttrs(ctx, slog.LevelWarn, "Retrying Flickr call",
+			slog.String("method", method),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.String("error", redactString(err.Error())))
+		if onRetry != nil {
+			onRetry(attempt, err, delay)
+		}
+	}
+	return p.Do(ctx, fn)
+}
@@ -0,0 +1,76 @@
+package flickr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogging(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			fmt.Fprint(w, `<rsp stat="fail"><err code="105" msg="Service currently unavailable" /></rsp>`)
+			return
+		}
+		fmt.Fprint(w, `<rsp stat="ok"><method>flickr.test.echo</method></rsp>`)
+	})
+	client.Auth = map[string]string{"oauth_token": "secret-token", "oauth_consumer_key": "key"}
+	var out bytes.Buffer
+	logger, err := NewLogger(&out, "json", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Logger = logger
+
+	request := client.NewRequest(http.MethodGet, map[string]string{"method": "flickr.test.echo"})
+	if _, err := request.ExecuteWithPolicyContext(context.Background(), &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if strings.Contains(out.String(), "secret-token") {
+		t.Errorf("token leaked into the log:\n%s", out.String())
+	}
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected failure, retry and success records, got %d", len(records))
+	}
+	if records[0]["level"] != "WARN" || records[0]["msg"] != "Flickr call failed" || records[0]["method"] != "flickr.test.echo" {
+		t.Errorf("unexpected failure record %v", records[0])
+	}
+	if records[1]["msg"] != "Retrying Flickr call" || records[1]["attempt"] != 1.0 {
+		t.Errorf("unexpected retry record %v", records[1])
+	}
+	success := records[2]
+	args, _ := success["args"].(map[string]any)
+	if success["level"] != "DEBUG" || success["id"] == "" || success["id"] == records[0]["id"] || success["status"] != 200.0 {
+		t.Errorf("unexpected success record %v", success)
+	}
+	if args["oauth_token"] != redacted || args["oauth_signature"] != redacted || args["oauth_consumer_key"] != "key" {
+		t.Errorf("unexpected args %v", args)
+	}
+
+	if _, err := NewLogger(&out, "xml", "info"); err == nil {
+		t.Error("expected an unknown format to fail")
+	}
+}
+
+func TestRedactString(t *testing.T) {
+	got := redactString(`Get "http://x/?oauth_signature=a%2Bb&oauth_token=t&photo_id=1": EOF`)
+	if want := `Get "http://x/?oauth_signature=REDACTED&oauth_token=REDACTED&photo_id=1": EOF`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
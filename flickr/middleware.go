@@ -0,0 +1,124 @@
+package flickr
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Call describes one HTTP round trip to Flickr as seen by Middleware.
+type Call struct {
+	// Id tells the log lines of one call apart from those of others.
+	Id string
+	// Method is the API method, "upload" and "replace" for files, or
+	// "request_token" and "access_token" for the OAuth flow.
+	Method     string
+	HTTPMethod string
+	Endpoint   string
+	// Args are the arguments of the request. Changes made in BeforeSign
+	// are signed and sent.
+	Args map[string]string
+	// Secret is the signing key, see SigningKey. BeforeSign may replace it
+	// together with oauth_token, e.g. after refreshing the token.
+	Secret string
+	// Header is added to the HTTP request.
+	Header http.Header
+	Start  time.Time
+	// Response and Body are set once Flickr answered; the response body is
+	// already read into Body, which AfterResponse may replace.
+	Response *http.Response
+	Body     []byte
+}
+
+// Middleware hooks into every request a client sends, except results served
+// from its Cache. BeforeSign runs in the order of Client.Middleware,
+// AfterResponse and OnError in reverse, so the first middleware wraps all
+// others.
+type Middleware interface {
+	// BeforeSign may change the call or fail it before anything is sent.
+	BeforeSign(ctx context.Context, call *Call) error
+	// AfterResponse sees the raw answer before it is decoded; an error
+	// fails the call.
+	AfterResponse(ctx context.Context, call *Call) error
+	// OnError sees every failed call and returns the error to report,
+	// which may be a different one.
+	OnError(ctx context.Context, call *Call, err error) error
+}
+
+// MiddlewareFuncs is a Middleware made of functions; nil ones do nothing.
+type MiddlewareFuncs struct {
+	BeforeSignFunc    func(ctx context.Context, call *Call) error
+	AfterResponseFunc func(ctx context.Context, call *Call) error
+	OnErrorFunc       func(ctx context.Context, call *Call, err error) error
+}
+
+func (m MiddlewareFuncs) BeforeSign(ctx context.Context, call *Call) error {
+	if m.BeforeSignFunc == nil {
+		return nil
+	}
+	return m.BeforeSignFunc(ctx, call)
+}
+
+func (m MiddlewareFuncs) AfterResponse(ctx context.Context, call *Call) error {
+	if m.AfterResponseFunc == nil {
+		return nil
+	}
+	return m.AfterResponseFunc(ctx, call)
+}
+
+func (m MiddlewareFuncs) OnError(ctx context.Context, call *Call, err error) error {
+	if m.OnErrorFunc == nil {
+		return err
+	}
+	return m.OnErrorFunc(ctx, call, err)
+}
+
+// roundTrip signs request for endpoint, sends the HTTP request made by
+// build and decodes the answer, running the client's middleware around it.
+func (c *Client) roundTrip(ctx context.Context, request *Request, method string, endpoint string, format string, build func() (*http.Request, error)) (*Response, error) {
+	call := &Call{
+		Id:         newCallId(),
+		Method:     method,
+		HTTPMethod: request.httpMethod,
+		Endpoint:   endpoint,
+		Args:       request.args,
+		Secret:     request.secret,
+		Header:     make(http.Header),
+		Start:      time.Now(),
+	}
+	response, err := c.roundTripCall(ctx, request, call, format, build)
+	c.logCall(ctx, call, err)
+	if err != nil {
+		for i := len(c.Middleware) - 1; i >= 0; i-- {
+			err = c.Middleware[i].OnError(ctx, call, err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (c *Client) roundTripCall(ctx context.Context, request *Request, call *Call, format string, build func() (*http.Request, error)) (*Response, error) {
+	for _, m := range c.Middleware {
+		if err := m.BeforeSign(ctx, call); err != nil {
+			return nil, err
+		}
+	}
+	request.args, request.secret = call.Args, call.Secret
+	if err := request.sign(call.Endpoint); err != nil {
+		return nil, err
+	}
+	httpRequest, err := build()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range call.Header {
+		httpRequest.Header[k] = v
+	}
+	response, err := c.send(ctx, call, httpRequest, format)
+	if err := checkError(err, response, call.Method); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,147 @@
+package flickr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	calls, injected := 0, true
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if !injected {
+			fmt.Fprint(w, `<rsp stat="ok"><sizes /></rsp>`)
+			return
+		}
+		if r.Header.Get("X-Trace") != "abc" {
+			t.Errorf("missing injected header")
+		}
+		if r.URL.Path == "/upload" {
+			fmt.Fprint(w, `<rsp stat="ok"><photoid>7</photoid></rsp>`)
+			return
+		}
+		r.ParseForm()
+		if r.Form.Get("extras") != "url_o" {
+			t.Errorf("arg added before signing was not sent: %v", r.Form)
+		}
+		fmt.Fprint(w, `<rsp stat="ok"><sizes><size label="Original" source="http://example.com/o.jpg" /></sizes></rsp>`)
+	})
+
+	var order []string
+	record := func(name string) Middleware {
+		return MiddlewareFuncs{
+			BeforeSignFunc: func(ctx context.Context, call *Call) error {
+				order = append(order, name+".before")
+				return nil
+			},
+			AfterResponseFunc: func(ctx context.Context, call *Call) error {
+				order = append(order, name+".after")
+				return nil
+			},
+			OnErrorFunc: func(ctx context.Context, call *Call, err error) error {
+				order = append(order, name+".error")
+				return err
+			},
+		}
+	}
+	inject := MiddlewareFuncs{
+		BeforeSignFunc: func(ctx context.Context, call *Call) error {
+			if call.Args["oauth_signature"] != "" && call.Method != uploadMethod {
+				t.Error("BeforeSign ran after signing")
+			}
+			call.Header.Set("X-Trace", "abc")
+			if call.Method == "flickr.photos.getSizes" {
+				call.Args["extras"] = "url_o"
+			}
+			return nil
+		},
+	}
+	client.Middleware = []Middleware{record("outer"), inject, record("inner")}
+	ctx := context.Background()
+
+	if _, err := client.Photos().GetSizes(ctx, "1"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if got := strings.Join(order, " "); got != "outer.before inner.before inner.after outer.after" {
+		t.Errorf("unexpected order %s", got)
+	}
+	if photoId, err := client.NewRequest(http.MethodPost, nil).Upload(writeTestJpeg(t)); err != nil || photoId != "7" {
+		t.Errorf("upload through middleware: %q, %v", photoId, err)
+	}
+
+	// Fault injection: turn the answer into an API failure
+	order, injected = nil, false
+	client.Middleware = []Middleware{record("outer"), MiddlewareFuncs{
+		AfterResponseFunc: func(ctx context.Context, call *Call) error {
+			call.Body = []byte(`<rsp stat="fail"><err code="105" msg="Service currently unavailable" /></rsp>`)
+			return nil
+		},
+	}}
+	if _, err := client.Photos().GetSizes(ctx, "1"); !errors.Is(err, ErrServiceUnavailable) {
+		t.Errorf("expected the injected failure, got %v", err)
+	}
+	if got := strings.Join(order, " "); got != "outer.before outer.after outer.error" {
+		t.Errorf("unexpected order %s", got)
+	}
+
+	// A failing BeforeSign stops the call, OnError may replace the error
+	calls = 0
+	replaced := errors.New("replaced")
+	client.Middleware = []Middleware{MiddlewareFuncs{
+		BeforeSignFunc: func(ctx context.Context, call *Call) error {
+			return errors.New("offline")
+		},
+		OnErrorFunc: func(ctx context.Context, call *Call, err error) error {
+			if err.Error() != "offline" || call.Method != "flickr.photos.getSizes" {
+				t.Errorf("unexpected error %v for %s", err, call.Method)
+			}
+			return replaced
+		},
+	}}
+	if _, err := client.Photos().GetSizes(ctx, "1"); err != replaced {
+		t.Errorf("expected the replaced error, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("%d requests sent after BeforeSign failed", calls)
+	}
+}
+
+func TestMiddlewareRefreshToken(t *testing.T) {
+	var endpoint string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		args := make(map[string]string)
+		for k := range r.Form {
+			args[k] = r.Form.Get(k)
+		}
+		signature := args["oauth_signature"]
+		delete(args, "oauth_signature")
+		base, err := BaseString(r.Method, endpoint, args)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if args["oauth_token"] != "fresh-token" || signature != (&Signer{}).signature(base, SigningKey("consumer", "fresh-secret")) {
+			fmt.Fprint(w, `<rsp stat="fail"><err code="98" msg="Invalid auth token" /></rsp>`)
+			return
+		}
+		fmt.Fprint(w, `<rsp stat="ok"><user id="1" /></rsp>`)
+	})
+	endpoint = client.APIEndpoint
+	client.Auth = map[string]string{"oauth_token": "stale-token", "oauth_consumer_key": "key"}
+	client.Secret = SigningKey("consumer", "stale-secret")
+	client.Middleware = []Middleware{MiddlewareFuncs{
+		BeforeSignFunc: func(ctx context.Context, call *Call) error {
+			call.Args["oauth_token"] = "fresh-token"
+			call.Secret = SigningKey("consumer", "fresh-secret")
+			return nil
+		},
+	}}
+	if _, err := client.NewRequest(http.MethodGet, map[string]string{"method": "flickr.test.login"}).Execute(); err != nil {
+		t.Errorf("refreshed token was not signed with its secret: %v", err)
+	}
+}
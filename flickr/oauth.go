@@ -0,0 +1,148 @@
+package flickr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+const (
+	requestTokenEndpoint = "https://www.flickr.com/services/oauth/request_token"
+	authorizeEndpoint    = "https://www.flickr.com/services/oauth/authorize"
+	accessTokenEndpoint  = "https://www.flickr.com/services/oauth/access_token"
+
+	// OutOfBand is the callback to use when the user copies the verifier by hand.
+	OutOfBand = "oob"
+
+	// requestTokenMethod and accessTokenMethod stand in for the API method
+	// name of the token requests.
+	requestTokenMethod = "request_token"
+	accessTokenMethod  = "access_token"
+	// formatOAuth marks the url-encoded answers of the token endpoints.
+	formatOAuth = "oauth"
+)
+
+type RequestToken struct {
+	Token             string
+	Secret            string
+	CallbackConfirmed bool
+}
+
+type AccessToken struct {
+	Token    string
+	Secret   string
+	UserNsid string
+	Username string
+	Fullname string
+}
+
+// GetRequestToken starts the authorization flow. callback is either
+// OutOfBand or the URL Flickr redirects to with the oauth_verifier.
+func (c *Client) GetRequestToken(consumerKey string, consumerSecret string, callback string) (*RequestToken, error) {
+	if callback == "" {
+		callback = OutOfBand
+	}
+	args := map[string]string{
+		"oauth_consumer_key": consumerKey,
+		"oauth_callback":     callback,
+	}
+	values, err := c.oauthCall(requestTokenMethod, c.requestTokenEndpoint(), args, SigningKey(consumerSecret, ""))
+	if err != nil {
+		return nil, err
+	}
+	token := &RequestToken{
+		Token:             values.Get("oauth_token"),
+		Secret:            values.Get("oauth_token_secret"),
+		CallbackConfirmed: values.Get("oauth_callback_confirmed") == "true",
+	}
+	if token.Token == "" {
+		return nil, errors.New("No oauth_token in request token response")
+	}
+	return token, nil
+}
+
+// AuthorizeURL is the page the user visits to grant access. perms is one of
+// read, write or delete; empty lets Flickr pick its default.
+func (c *Client) AuthorizeURL(token *RequestToken, perms string) string {
+	query := url.Values{}
+	query.Set("oauth_token", token.Token)
+	if perms != "" {
+		query.Set("perms", perms)
+	}
+	return c.authorizeEndpoint() + "?" + query.Encode()
+}
+
+// GetAccessToken exchanges an authorized request token and its verifier for
+// the long-lived access token.
+func (c *Client) GetAccessToken(consumerKey string, consumerSecret string, token *RequestToken, verifier string) (*AccessToken, error) {
+	args := map[string]string{
+		"oauth_consumer_key": consumerKey,
+		"oauth_token":        token.Token,
+		"oauth_verifier":     verifier,
+	}
+	values, err := c.oauthCall(accessTokenMethod, c.accessTokenEndpoint(), args, SigningKey(consumerSecret, token.Secret))
+	if err != nil {
+		return nil, err
+	}
+	accessToken := &AccessToken{
+		Token:    values.Get("oauth_token"),
+		Secret:   values.Get("oauth_token_secret"),
+		UserNsid: values.Get("user_nsid"),
+		Username: values.Get("username"),
+		Fullname: values.Get("fullname"),
+	}
+	if accessToken.Token == "" {
+		return nil, errors.New("No oauth_token in access token response")
+	}
+	return accessToken, nil
+}
+
+// SetAccessToken makes the client sign its requests with the given token.
+func (c *Client) SetAccessToken(consumerKey string, consumerSecret string, token *AccessToken) {
+	if c.Auth == nil {
+		c.Auth = make(map[string]string)
+	}
+	c.Auth["oauth_consumer_key"] = consumerKey
+	c.Auth["oauth_token"] = token.Token
+	c.Secret = SigningKey(consumerSecret, token.Secret)
+}
+
+// oauthCall gets a token from endpoint, through the client's rate limiter
+// and middleware like any other call.
+func (c *Client) oauthCall(method string, endpoint string, args map[string]string, secret string) (url.Values, error) {
+	ctx := context.Background()
+	if err := c.RateLimiter.call(ctx); err != nil {
+		return nil, err
+	}
+	request := newRequest(http.MethodGet, nil, args, secret)
+	request.client = c
+	response, err := c.roundTrip(ctx, request, method, endpoint, formatOAuth, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+encodeQuery(request.args), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return url.ParseQuery(response.Payload)
+}
+
+func (c *Client) requestTokenEndpoint() string {
+	if c.RequestTokenEndpoint != "" {
+		return c.RequestTokenEndpoint
+	}
+	return requestTokenEndpoint
+}
+
+func (c *Client) authorizeEndpoint() string {
+	if c.AuthorizeEndpoint != "" {
+		return c.AuthorizeEndpoint
+	}
+	return authorizeEndpoint
+}
+
+func (c *Client) accessTokenEndpoint() string {
+	if c.AccessTokenEndpoint != "" {
+		return c.AccessTokenEndpoint
+	}
+	return accessTokenEndpoint
+}
@@ -0,0 +1,240 @@
+package flickr
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Page is one page of the result of a paged list method.
+type Page[T any] struct {
+	Items   []T
+	Page    int
+	Pages   int
+	PerPage int
+	Total   int
+}
+
+// PageFunc fetches page number page (counting from 1) with perPage items;
+// perPage 0 leaves the page size to Flickr.
+type PageFunc[T any] func(ctx context.Context, page int, perPage int) (*Page[T], error)
+
+// Paginator walks all pages of a list method, fetching each page only when
+// the items before it have been consumed:
+//
+//	p := flickr.NewPaginator(ctx, 500, fetch)
+//	for p.Next() {
+//		item := p.Item()
+//	}
+//	if err := p.Err(); err != nil {
+//	}
+type Paginator[T any] struct {
+	ctx     context.Context
+	fetch   PageFunc[T]
+	perPage int
+	current *Page[T]
+	index   int
+	item    T
+	err     error
+	done    bool
+}
+
+func NewPaginator[T any](ctx context.Context, perPage int, fetch PageFunc[T]) *Paginator[T] {
+	return &Paginator[T]{ctx: ctx, fetch: fetch, perPage: perPage}
+}
+
+// Next advances to the next item, fetching the next page when needed. It
+// returns false when all items are consumed or an error occurred.
+func (p *Paginator[T]) Next() bool {
+	for !p.done && (p.current == nil || p.index >= len(p.current.Items)) {
+		if p.current != nil && (p.current.Page >= p.current.Pages || len(p.current.Items) == 0) {
+			p.done = true
+			break
+		}
+		if err := p.ctx.Err(); err != nil {
+			p.err, p.done = err, true
+			break
+		}
+		next := 1
+		if p.current != nil {
+			next = p.current.Page + 1
+		}
+		page, err := p.fetch(p.ctx, next, p.perPage)
+		if err != nil {
+			p.err, p.done = err, true
+			break
+		}
+		if page.Page == 0 {
+			page.Page = next
+		}
+		p.current, p.index = page, 0
+	}
+	if p.done {
+		return false
+	}
+	p.item = p.current.Items[p.index]
+	p.index++
+	return true
+}
+
+// Item is the item Next advanced to.
+func (p *Paginator[T]) Item() T {
+	return p.item
+}
+
+func (p *Paginator[T]) Err() error {
+	return p.err
+}
+
+// Total is the number of items over all pages as reported by Flickr. It is
+// known once the first page was fetched, i.e. after the first call to Next.
+func (p *Paginator[T]) Total() int {
+	if p.current == nil {
+		return 0
+	}
+	return p.current.Total
+}
+
+// Pages is the number of pages, known after the first call to Next.
+func (p *Paginator[T]) Pages() int {
+	if p.current == nil {
+		return 0
+	}
+	return p.current.Pages
+}
+
+// Page is the number of the page the current item is on.
+func (p *Paginator[T]) Page() int {
+	if p.current == nil {
+		return 0
+	}
+	return p.current.Page
+}
+
+// All consumes the remaining items.
+func (p *Paginator[T]) All() ([]T, error) {
+	var items []T
+	for p.Next() {
+		items = append(items, p.Item())
+	}
+	return items, p.Err()
+}
+
+// PageMethod turns any paged list method into a PageFunc. The result is
+// looked up under key, e.g. "photos", and holds the items as itemKey
+// elements, e.g. "photo", next to the page, pages, perpage and total
+// attributes.
+func PageMethod[T any](client *Client, method string, args map[string]string, key string, itemKey string) PageFunc[T] {
+	return func(ctx context.Context, page int, perPage int) (*Page[T], error) {
+		pageArgs := make(map[string]string)
+		for k, v := range args {
+			pageArgs[k] = v
+		}
+		(&PageOptions{Page: page, PerPage: perPage}).addArgs(pageArgs)
+		request := client.NewRequest(http.MethodGet, pageArgs)
+		request.args["method"] = method
+		payload, err := client.execute(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		return decodePage[T](request.Format(), payload, key, itemKey)
+	}
+}
+
+func decodePage[T any](format string, payload string, key string, itemKey string) (*Page[T], error) {
+	if format == FormatJSON {
+		var fields map[string]json.RawMessage
+		if err := Unmarshal(format, payload, key, &fields); err != nil {
+			return nil, err
+		}
+		page := &Page[T]{}
+		for name, dst := range map[string]*int{"page": &page.Page, "pages": &page.Pages, "perpage": &page.PerPage, "per_page": &page.PerPage, "total": &page.Total} {
+			var n flexInt
+			if raw, ok := fields[name]; ok && json.Unmarshal(raw, &n) == nil && n != 0 {
+				*dst = int(n)
+			}
+		}
+		if raw, ok := fields[itemKey]; ok {
+			if err := json.Unmarshal(raw, &page.Items); err != nil {
+				return nil, err
+			}
+		}
+		return page, nil
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(payload))
+	page := &Page[T]{}
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				for _, attr := range t.Attr {
+					n, _ := strconv.Atoi(attr.Value)
+					switch attr.Name.Local {
+					case "page":
+						page.Page = n
+					case "pages":
+						page.Pages = n
+					case "perpage", "per_page":
+						page.PerPage = n
+					case "total":
+						page.Total = n
+					}
+				}
+			} else if depth == 2 && t.Name.Local == itemKey {
+				var item T
+				if err := decoder.DecodeElement(&item, &t); err != nil {
+					return nil, err
+				}
+				page.Items = append(page.Items, item)
+				depth--
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+	if depth != 0 {
+		return nil, errors.New("Unbalanced XML in " + key + " page")
+	}
+	return page, nil
+}
+
+// GetListPaginator walks all photosets of userId, or of the calling user.
+func (s *PhotosetsService) GetListPaginator(ctx context.Context, userId string, perPage int) *Paginator[Photoset] {
+	args := make(map[string]string)
+	if userId != "" {
+		args["user_id"] = userId
+	}
+	return NewPaginator(ctx, perPage, PageMethod[Photoset](s.client, "flickr.photosets.getList", args, "photosets", "photoset"))
+}
+
+// GetPhotosPaginator walks all photos of a photoset. The page options in
+// opts are ignored.
+func (s *PhotosetsService) GetPhotosPaginator(ctx context.Context, photosetId string, opts *PhotosetPhotosOptions, perPage int) *Paginator[Photo] {
+	return NewPaginator(ctx, perPage, func(ctx context.Context, page int, perPage int) (*Page[Photo], error) {
+		o := PhotosetPhotosOptions{}
+		if opts != nil {
+			o = *opts
+		}
+		o.PageOptions = PageOptions{Page: page, PerPage: perPage}
+		photoset, err := s.GetPhotos(ctx, photosetId, &o)
+		if err != nil {
+			return nil, err
+		}
+		return &Page[Photo]{photoset.Photo, photoset.Page, photoset.Pages, photoset.PerPage, photoset.Total}, nil
+	})
+}
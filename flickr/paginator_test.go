@@ -0,0 +1,61 @@
+package flickr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestPaginator(t *testing.T) {
+	var fetched []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		page := r.Form.Get("page")
+		fetched = append(fetched, page)
+		if r.Form.Get("per_page") != "2" {
+			t.Errorf("unexpected per_page %q", r.Form.Get("per_page"))
+		}
+		n, _ := strconv.Atoi(page)
+		if r.Form.Get("format") == FormatJSON {
+			fmt.Fprintf(w, `{"photos":{"page":%d,"pages":"2","perpage":2,"total":"3","photo":[{"id":"%d","title":"p%d"}]},"stat":"ok"}`, n, n, n)
+			return
+		}
+		fmt.Fprintf(w, `<rsp stat="ok"><photos page="%d" pages="2" perpage="2" total="3">`, n)
+		for i := 0; i < 3-n; i++ {
+			fmt.Fprintf(w, `<photo id="%d-%d" title="p" />`, n, i)
+		}
+		fmt.Fprint(w, `</photos></rsp>`)
+	})
+	ctx := context.Background()
+	fetch := PageMethod[Photo](client, "flickr.people.getPhotos", map[string]string{"user_id": "me"}, "photos", "photo")
+
+	p := NewPaginator(ctx, 2, fetch)
+	if !p.Next() || p.Item().Id != "1-0" || p.Total() != 3 || p.Pages() != 2 {
+		t.Fatalf("unexpected first item %+v, total %d", p.Item(), p.Total())
+	}
+	if len(fetched) != 1 {
+		t.Errorf("fetched %v before consuming the first page", fetched)
+	}
+	rest, err := p.All()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(rest) != 2 || rest[1].Id != "2-0" || p.Page() != 2 || fmt.Sprint(fetched) != "[1 2]" {
+		t.Errorf("unexpected items %+v after fetching %v", rest, fetched)
+	}
+
+	client.Format = FormatJSON
+	items, err := NewPaginator(ctx, 2, fetch).All()
+	if err != nil || len(items) != 2 || items[1].Title != "p2" {
+		t.Errorf("unexpected JSON items %+v, %v", items, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	p = NewPaginator(cancelled, 2, fetch)
+	if p.Next() || p.Err() != context.Canceled {
+		t.Errorf("expected cancellation, got %v", p.Err())
+	}
+}
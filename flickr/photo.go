@@ -0,0 +1,267 @@
+package flickr
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Extras are the optional fields list methods can add to each photo.
+type Extras []string
+
+const (
+	ExtraDescription    = "description"
+	ExtraLicense        = "license"
+	ExtraDateUpload     = "date_upload"
+	ExtraDateTaken      = "date_taken"
+	ExtraOwnerName      = "owner_name"
+	ExtraIconServer     = "icon_server"
+	ExtraOriginalFormat = "original_format"
+	ExtraLastUpdate     = "last_update"
+	ExtraGeo            = "geo"
+	ExtraTags           = "tags"
+	ExtraMachineTags    = "machine_tags"
+	ExtraODims          = "o_dims"
+	ExtraViews          = "views"
+	ExtraMedia          = "media"
+	ExtraPathAlias      = "path_alias"
+	ExtraURLSq          = "url_sq"
+	ExtraURLQ           = "url_q"
+	ExtraURLT           = "url_t"
+	ExtraURLS           = "url_s"
+	ExtraURLN           = "url_n"
+	ExtraURLW           = "url_w"
+	ExtraURLM           = "url_m"
+	ExtraURLZ           = "url_z"
+	ExtraURLC           = "url_c"
+	ExtraURLL           = "url_l"
+	ExtraURLH           = "url_h"
+	ExtraURLK           = "url_k"
+	ExtraURL3K          = "url_3k"
+	ExtraURL4K          = "url_4k"
+	ExtraURLF           = "url_f"
+	ExtraURL5K          = "url_5k"
+	ExtraURL6K          = "url_6k"
+	ExtraURLO           = "url_o"
+)
+
+// sizeSuffixes maps the suffix of the url_* extras to the size labels used
+// by flickr.photos.getSizes, smallest first.
+var sizeSuffixes = []struct{ suffix, label string }{
+	{"sq", "Square"},
+	{"t", "Thumbnail"},
+	{"q", "Large Square"},
+	{"s", "Small"},
+	{"n", "Small 320"},
+	{"w", "Small 400"},
+	{"m", "Medium"},
+	{"z", "Medium 640"},
+	{"c", "Medium 800"},
+	{"l", "Large"},
+	{"h", "Large 1600"},
+	{"k", "Large 2048"},
+	{"3k", "X-Large 3K"},
+	{"4k", "X-Large 4K"},
+	// f is the 4096 pixel size of 2:1 panoramas
+	{"f", "X-Large 4K 2:1"},
+	{"5k", "X-Large 5K"},
+	{"6k", "X-Large 6K"},
+	{"o", "Original"},
+}
+
+var (
+	URLExtras = Extras{ExtraURLSq, ExtraURLT, ExtraURLQ, ExtraURLS, ExtraURLN, ExtraURLW, ExtraURLM,
+		ExtraURLZ, ExtraURLC, ExtraURLL, ExtraURLH, ExtraURLK, ExtraURL3K, ExtraURL4K, ExtraURLF,
+		ExtraURL5K, ExtraURL6K, ExtraURLO}
+	AllExtras = append(Extras{ExtraDescription, ExtraLicense, ExtraDateUpload, ExtraDateTaken,
+		ExtraOwnerName, ExtraIconServer, ExtraOriginalFormat, ExtraLastUpdate, ExtraGeo, ExtraTags,
+		ExtraMachineTags, ExtraODims, ExtraViews, ExtraMedia, ExtraPathAlias}, URLExtras...)
+)
+
+func (e Extras) String() string {
+	return strings.Join(e, ",")
+}
+
+// Photo is a photo as listed by photosets.getPhotos, photos.search and the
+// like. Most fields are only filled in when asked for with the matching
+// extra.
+type Photo struct {
+	Id          string
+	Owner       string
+	Secret      string
+	Server      string
+	Farm        int
+	Title       string
+	IsPublic    bool
+	IsFriend    bool
+	IsFamily    bool
+	IsPrimary   bool
+	Description string
+	License     string
+	DateUpload  time.Time
+	DateTaken   time.Time
+	// DateTakenGranularity is 0 for an exact date, 4 for the month, 6 for
+	// the year and 8 for the circa year.
+	DateTakenGranularity int
+	DateTakenUnknown     bool
+	OwnerName            string
+	IconServer           string
+	IconFarm             int
+	OriginalSecret       string
+	OriginalFormat       string
+	LastUpdate           time.Time
+	Latitude             float64
+	Longitude            float64
+	Accuracy             int
+	// GeoContext is 0 when not defined, 1 for indoors and 2 for outdoors.
+	GeoContext     int
+	PlaceId        string
+	Woeid          string
+	Tags           []string
+	MachineTags    []string
+	OriginalWidth  int
+	OriginalHeight int
+	Views          int
+	Media          string
+	MediaStatus    string
+	PathAlias      string
+	// Sizes holds every size asked for with the url_* extras, smallest
+	// first. The Url* fields below repeat their sources.
+	Sizes []Size
+	UrlSq string
+	UrlT  string
+	UrlQ  string
+	UrlS  string
+	UrlN  string
+	UrlW  string
+	UrlM  string
+	UrlZ  string
+	UrlC  string
+	UrlL  string
+	UrlH  string
+	UrlK  string
+	Url3K string
+	Url4K string
+	UrlF  string
+	Url5K string
+	Url6K string
+	UrlO  string
+}
+
+// Size returns the size with the given label, e.g. "Original", or nil.
+func (p *Photo) Size(label string) *Size {
+	for i := range p.Sizes {
+		if p.Sizes[i].Label == label {
+			return &p.Sizes[i]
+		}
+	}
+	return nil
+}
+
+// Largest returns the largest size asked for, or nil when no url_* extra was.
+func (p *Photo) Largest() *Size {
+	if len(p.Sizes) == 0 {
+		return nil
+	}
+	return &p.Sizes[len(p.Sizes)-1]
+}
+
+func (p *Photo) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	attrs := make(map[string]string, len(start.Attr))
+	for _, attr := range start.Attr {
+		attrs[attr.Name.Local] = attr.Value
+	}
+	var children struct {
+		Description string `xml:"description"`
+	}
+	if err := d.DecodeElement(&children, &start); err != nil {
+		return err
+	}
+	attrs["description"] = children.Description
+	*p = photoFromAttrs(attrs)
+	return nil
+}
+
+func (p *Photo) UnmarshalJSON(b []byte) error {
+	var fields map[string]flexString
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	attrs := make(map[string]string, len(fields))
+	for k, v := range fields {
+		attrs[k] = string(v)
+	}
+	*p = photoFromAttrs(attrs)
+	return nil
+}
+
+func photoFromAttrs(a map[string]string) Photo {
+	atoi := func(k string) int {
+		n, _ := strconv.Atoi(a[k])
+		return n
+	}
+	atof := func(k string) float64 {
+		f, _ := strconv.ParseFloat(a[k], 64)
+		return f
+	}
+	p := Photo{
+		Id:                   a["id"],
+		Owner:                a["owner"],
+		Secret:               a["secret"],
+		Server:               a["server"],
+		Farm:                 atoi("farm"),
+		Title:                a["title"],
+		IsPublic:             atoi("ispublic") != 0,
+		IsFriend:             atoi("isfriend") != 0,
+		IsFamily:             atoi("isfamily") != 0,
+		IsPrimary:            atoi("isprimary") != 0,
+		Description:          a["description"],
+		License:              a["license"],
+		DateUpload:           parseUnix(a["dateupload"]),
+		DateTaken:            parseTaken(a["datetaken"]),
+		DateTakenGranularity: atoi("datetakengranularity"),
+		DateTakenUnknown:     atoi("datetakenunknown") != 0,
+		OwnerName:            a["ownername"],
+		IconServer:           a["iconserver"],
+		IconFarm:             atoi("iconfarm"),
+		OriginalSecret:       a["originalsecret"],
+		OriginalFormat:       a["originalformat"],
+		LastUpdate:           parseUnix(a["lastupdate"]),
+		Latitude:             atof("latitude"),
+		Longitude:            atof("longitude"),
+		Accuracy:             atoi("accuracy"),
+		GeoContext:           atoi("context"),
+		PlaceId:              a["place_id"],
+		Woeid:                a["woeid"],
+		Tags:                 strings.Fields(a["tags"]),
+		MachineTags:          strings.Fields(a["machine_tags"]),
+		OriginalWidth:        atoi("o_width"),
+		OriginalHeight:       atoi("o_height"),
+		Views:                atoi("views"),
+		Media:                a["media"],
+		MediaStatus:          a["media_status"],
+		PathAlias:            a["pathalias"],
+	}
+	urls := map[string]*string{
+		"sq": &p.UrlSq, "t": &p.UrlT, "q": &p.UrlQ, "s": &p.UrlS, "n": &p.UrlN, "w": &p.UrlW,
+		"m": &p.UrlM, "z": &p.UrlZ, "c": &p.UrlC, "l": &p.UrlL, "h": &p.UrlH, "k": &p.UrlK,
+		"3k": &p.Url3K, "4k": &p.Url4K, "f": &p.UrlF, "5k": &p.Url5K, "6k": &p.Url6K, "o": &p.UrlO,
+	}
+	for _, s := range sizeSuffixes {
+		source := a["url_"+s.suffix]
+		if source == "" {
+			continue
+		}
+		*urls[s.suffix] = source
+		p.Sizes = append(p.Sizes, Size{
+			Label:  s.label,
+			Width:  atoi("width_" + s.suffix),
+			Height: atoi("height_" + s.suffix),
+			Source: source,
+			Media:  p.Media,
+		})
+	}
+	return p
+}
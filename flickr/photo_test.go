@@ -0,0 +1,61 @@
+package flickr
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestPhotoExtras(t *testing.T) {
+	xmlPhoto := `<photo id="1" owner="12@N01" secret="s" server="2" farm="66" title="Beach" ispublic="1" isfriend="0" isfamily="0"
+license="4" dateupload="1530000000" datetaken="2018-06-20 08:15:00" datetakengranularity="0" datetakenunknown="0"
+ownername="jane" iconserver="7" iconfarm="8" originalsecret="os" originalformat="jpg" lastupdate="1530000100"
+latitude="52.5" longitude="-1.25" accuracy="16" context="2" place_id="p" woeid="44" tags="sea sand" machine_tags="geo:lat=52"
+o_width="4000" o_height="3000" views="12" media="photo" media_status="ready" pathalias="jane"
+url_sq="http://example.com/sq.jpg" height_sq="75" width_sq="75" url_o="http://example.com/o.jpg" height_o="3000" width_o="4000">
+<description>Sunny</description></photo>`
+	jsonPhoto := `{"id":"1","owner":"12@N01","secret":"s","server":"2","farm":66,"title":"Beach","ispublic":1,"isfriend":0,"isfamily":0,
+"license":"4","dateupload":"1530000000","datetaken":"2018-06-20 08:15:00","datetakengranularity":"0","datetakenunknown":"0",
+"ownername":"jane","iconserver":"7","iconfarm":8,"originalsecret":"os","originalformat":"jpg","lastupdate":"1530000100",
+"latitude":52.5,"longitude":"-1.25","accuracy":"16","context":2,"place_id":"p","woeid":"44","tags":"sea sand","machine_tags":"geo:lat=52",
+"o_width":"4000","o_height":"3000","views":"12","media":"photo","media_status":"ready","pathalias":"jane",
+"url_sq":"http://example.com/sq.jpg","height_sq":75,"width_sq":"75","url_o":"http://example.com/o.jpg","height_o":"3000","width_o":4000,
+"description":{"_content":"Sunny"}}`
+
+	var fromXML, fromJSON Photo
+	if err := xml.Unmarshal([]byte(xmlPhoto), &fromXML); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := json.Unmarshal([]byte(jsonPhoto), &fromJSON); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for name, p := range map[string]Photo{"xml": fromXML, "json": fromJSON} {
+		if p.Title != "Beach" || p.Description != "Sunny" || p.Farm != 66 || !p.IsPublic || p.IconFarm != 8 ||
+			p.DateUpload.Unix() != 1530000000 || !p.DateTaken.Equal(time.Date(2018, 6, 20, 8, 15, 0, 0, time.UTC)) ||
+			p.LastUpdate.Unix() != 1530000100 || p.Latitude != 52.5 || p.Longitude != -1.25 || p.Accuracy != 16 || p.GeoContext != 2 ||
+			len(p.Tags) != 2 || p.Tags[1] != "sand" || p.MachineTags[0] != "geo:lat=52" ||
+			p.OriginalWidth != 4000 || p.Views != 12 || p.PathAlias != "jane" || p.UrlO != "http://example.com/o.jpg" {
+			t.Errorf("%s: unexpected photo %+v", name, p)
+		}
+		if len(p.Sizes) != 2 || p.Sizes[0].Label != "Square" || p.Largest().Width != 4000 || p.Size("Original").Height != 3000 {
+			t.Errorf("%s: unexpected sizes %+v", name, p.Sizes)
+		}
+	}
+	if got := (Extras{ExtraURLO, ExtraDateTaken}).String(); got != "url_o,date_taken" {
+		t.Errorf("unexpected extras %q", got)
+	}
+}
+
+func TestSizeSuffixes(t *testing.T) {
+	labels := make(map[string]string)
+	for _, s := range sizeSuffixes {
+		if other, ok := labels[s.label]; ok {
+			t.Errorf("url_%s and url_%s share the label %q", other, s.suffix, s.label)
+		}
+		labels[s.label] = s.suffix
+	}
+	if len(sizeSuffixes) != len(URLExtras) {
+		t.Errorf("%d size suffixes for %d url extras", len(sizeSuffixes), len(URLExtras))
+	}
+}
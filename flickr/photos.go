@@ -0,0 +1,469 @@
+package flickr
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SafetyLevelSafe       = 1
+	SafetyLevelModerate   = 2
+	SafetyLevelRestricted = 3
+
+	ContentTypePhoto      = 1
+	ContentTypeScreenshot = 2
+	ContentTypeOther      = 3
+
+	// Who may comment on or add notes and tags to a photo.
+	PermNobody        = 0
+	PermFriendsFamily = 1
+	PermContacts      = 2
+	PermEveryone      = 3
+
+	takenDateLayout = "2006-01-02 15:04:05"
+)
+
+type PhotoOwner struct {
+	Nsid     string `xml:"nsid,attr" json:"nsid"`
+	Username string `xml:"username,attr" json:"username"`
+	Realname string `xml:"realname,attr" json:"realname"`
+	Location string `xml:"location,attr" json:"location"`
+}
+
+type Tag struct {
+	Id         string `xml:"id,attr" json:"id"`
+	Author     string `xml:"author,attr" json:"author"`
+	Raw        string `xml:"raw,attr" json:"raw"`
+	Value      string `xml:",chardata" json:"_content"`
+	MachineTag bool   `xml:"-" json:"-"`
+}
+
+type PhotoUrl struct {
+	Type string `xml:"type,attr" json:"type"`
+	Url  string `xml:",chardata" json:"_content"`
+}
+
+type Visibility struct {
+	IsPublic bool
+	IsFriend bool
+	IsFamily bool
+}
+
+type Permissions struct {
+	PermComment int
+	PermAddMeta int
+}
+
+// PhotoInfo is the result of flickr.photos.getInfo.
+type PhotoInfo struct {
+	Id               string
+	Secret           string
+	Server           string
+	Farm             int
+	OriginalSecret   string
+	OriginalFormat   string
+	License          string
+	Rotation         int
+	Views            int
+	Media            string
+	SafetyLevel      int
+	IsFavorite       bool
+	Owner            PhotoOwner
+	Title            string
+	Description      string
+	Visibility       Visibility
+	Permissions      Permissions
+	Posted           time.Time
+	Taken            time.Time
+	TakenGranularity int
+	LastUpdate       time.Time
+	Comments         int
+	Tags             []Tag
+	Urls             []PhotoUrl
+}
+
+type photoInfoFields struct {
+	Id             string     `xml:"id,attr" json:"id"`
+	Secret         string     `xml:"secret,attr" json:"secret"`
+	Server         string     `xml:"server,attr" json:"server"`
+	Farm           flexInt    `xml:"farm,attr" json:"farm"`
+	OriginalSecret string     `xml:"originalsecret,attr" json:"originalsecret"`
+	OriginalFormat string     `xml:"originalformat,attr" json:"originalformat"`
+	License        flexString `xml:"license,attr" json:"license"`
+	Rotation       flexInt    `xml:"rotation,attr" json:"rotation"`
+	Views          flexInt    `xml:"views,attr" json:"views"`
+	Media          string     `xml:"media,attr" json:"media"`
+	SafetyLevel    flexInt    `xml:"safety_level,attr" json:"safety_level"`
+	IsFavorite     flexInt    `xml:"isfavorite,attr" json:"isfavorite"`
+	Owner          PhotoOwner `xml:"owner" json:"owner"`
+	Title          flexString `xml:"title" json:"title"`
+	Description    flexString `xml:"description" json:"description"`
+	Visibility     struct {
+		IsPublic flexInt `xml:"ispublic,attr" json:"ispublic"`
+		IsFriend flexInt `xml:"isfriend,attr" json:"isfriend"`
+		IsFamily flexInt `xml:"isfamily,attr" json:"isfamily"`
+	} `xml:"visibility" json:"visibility"`
+	Permissions struct {
+		PermComment flexInt `xml:"permcomment,attr" json:"permcomment"`
+		PermAddMeta flexInt `xml:"permaddmeta,attr" json:"permaddmeta"`
+	} `xml:"permissions" json:"permissions"`
+	Dates struct {
+		Posted           flexString `xml:"posted,attr" json:"posted"`
+		Taken            flexString `xml:"taken,attr" json:"taken"`
+		TakenGranularity flexInt    `xml:"takengranularity,attr" json:"takengranularity"`
+		LastUpdate       flexString `xml:"lastupdate,attr" json:"lastupdate"`
+	} `xml:"dates" json:"dates"`
+	Comments flexString `xml:"comments" json:"comments"`
+	Tags     struct {
+		Tag []struct {
+			Tag
+			MachineTag flexInt `xml:"machine_tag,attr" json:"machine_tag"`
+		} `xml:"tag" json:"tag"`
+	} `xml:"tags" json:"tags"`
+	Urls struct {
+		Url []PhotoUrl `xml:"url" json:"url"`
+	} `xml:"urls" json:"urls"`
+}
+
+func (f *photoInfoFields) photoInfo() PhotoInfo {
+	info := PhotoInfo{
+		Id:             f.Id,
+		Secret:         f.Secret,
+		Server:         f.Server,
+		Farm:           int(f.Farm),
+		OriginalSecret: f.OriginalSecret,
+		OriginalFormat: f.OriginalFormat,
+		License:        string(f.License),
+		Rotation:       int(f.Rotation),
+		Views:          int(f.Views),
+		Media:          f.Media,
+		SafetyLevel:    int(f.SafetyLevel),
+		IsFavorite:     f.IsFavorite != 0,
+		Owner:          f.Owner,
+		Title:          string(f.Title),
+		Description:    string(f.Description),
+		Visibility: Visibility{
+			IsPublic: f.Visibility.IsPublic != 0,
+			IsFriend: f.Visibility.IsFriend != 0,
+			IsFamily: f.Visibility.IsFamily != 0,
+		},
+		Permissions: Permissions{
+			PermComment: int(f.Permissions.PermComment),
+			PermAddMeta: int(f.Permissions.PermAddMeta),
+		},
+		Posted:           parseUnix(string(f.Dates.Posted)),
+		Taken:            parseTaken(string(f.Dates.Taken)),
+		TakenGranularity: int(f.Dates.TakenGranularity),
+		LastUpdate:       parseUnix(string(f.Dates.LastUpdate)),
+		Urls:             f.Urls.Url,
+	}
+	info.Comments, _ = strconv.Atoi(strings.TrimSpace(string(f.Comments)))
+	for _, t := range f.Tags.Tag {
+		tag := t.Tag
+		tag.MachineTag = t.MachineTag != 0
+		info.Tags = append(info.Tags, tag)
+	}
+	return info
+}
+
+func (p *PhotoInfo) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var f photoInfoFields
+	if err := d.DecodeElement(&f, &start); err != nil {
+		return err
+	}
+	*p = f.photoInfo()
+	return nil
+}
+
+func (p *PhotoInfo) UnmarshalJSON(b []byte) error {
+	var f photoInfoFields
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+	*p = f.photoInfo()
+	return nil
+}
+
+// Size is one of the renditions listed by flickr.photos.getSizes.
+type Size struct {
+	Label  string `xml:"label,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+	Source string `xml:"source,attr"`
+	Url    string `xml:"url,attr"`
+	Media  string `xml:"media,attr"`
+}
+
+func (s *Size) UnmarshalJSON(b []byte) error {
+	var f struct {
+		Label  string  `json:"label"`
+		Width  flexInt `json:"width"`
+		Height flexInt `json:"height"`
+		Source string  `json:"source"`
+		Url    string  `json:"url"`
+		Media  string  `json:"media"`
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+	*s = Size{f.Label, int(f.Width), int(f.Height), f.Source, f.Url, f.Media}
+	return nil
+}
+
+type Sizes struct {
+	Size []Size `xml:"size" json:"size"`
+}
+
+// Find returns the size with the given label, e.g. "Original", or nil.
+func (s *Sizes) Find(label string) *Size {
+	for i := range s.Size {
+		if s.Size[i].Label == label {
+			return &s.Size[i]
+		}
+	}
+	return nil
+}
+
+// videoSizes are the labels of downloadable video sizes, best first.
+var videoSizes = []string{"Video Original", "1080p", "720p", "HD MP4", "Site MP4", "Mobile MP4"}
+
+// Video returns the best downloadable video size, "Video Original" when the
+// owner allows it, or nil for photos.
+func (s *Sizes) Video() *Size {
+	for _, label := range videoSizes {
+		if size := s.Find(label); size != nil {
+			return size
+		}
+	}
+	return nil
+}
+
+type Exif struct {
+	Tagspace   string
+	TagspaceId int
+	Tag        string
+	Label      string
+	Raw        string
+	Clean      string
+}
+
+type exifFields struct {
+	Tagspace   string     `xml:"tagspace,attr" json:"tagspace"`
+	TagspaceId flexInt    `xml:"tagspaceid,attr" json:"tagspaceid"`
+	Tag        flexString `xml:"tag,attr" json:"tag"`
+	Label      string     `xml:"label,attr" json:"label"`
+	Raw        flexString `xml:"raw" json:"raw"`
+	Clean      flexString `xml:"clean" json:"clean"`
+}
+
+func (f *exifFields) exif() Exif {
+	return Exif{f.Tagspace, int(f.TagspaceId), string(f.Tag), f.Label, string(f.Raw), string(f.Clean)}
+}
+
+func (e *Exif) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var f exifFields
+	if err := d.DecodeElement(&f, &start); err != nil {
+		return err
+	}
+	*e = f.exif()
+	return nil
+}
+
+func (e *Exif) UnmarshalJSON(b []byte) error {
+	var f exifFields
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+	*e = f.exif()
+	return nil
+}
+
+// PhotoExif is the result of flickr.photos.getExif.
+type PhotoExif struct {
+	Id     string `xml:"id,attr" json:"id"`
+	Camera string `xml:"camera,attr" json:"camera"`
+	Exif   []Exif `xml:"exif" json:"exif"`
+}
+
+// Find returns the clean value of the first tag with the given label,
+// falling back to its raw value.
+func (p *PhotoExif) Find(label string) (string, bool) {
+	for _, e := range p.Exif {
+		if e.Label == label {
+			if e.Clean != "" {
+				return e.Clean, true
+			}
+			return e.Raw, true
+		}
+	}
+	return "", false
+}
+
+// PhotoDates are the dates to change with SetDates. Zero times are left
+// unchanged.
+type PhotoDates struct {
+	Posted           time.Time
+	Taken            time.Time
+	TakenGranularity int
+}
+
+// PhotosService wraps the flickr.photos.* methods for a single photo.
+type PhotosService struct {
+	client *Client
+}
+
+func (c *Client) Photos() *PhotosService {
+	return &PhotosService{c}
+}
+
+// GetInfo returns the details of a photo. secret is optional and skips the
+// permission check when it matches.
+func (s *PhotosService) GetInfo(ctx context.Context, photoId string, secret string) (*PhotoInfo, error) {
+	args := map[string]string{"photo_id": photoId}
+	if secret != "" {
+		args["secret"] = secret
+	}
+	var info PhotoInfo
+	if err := s.client.call(ctx, http.MethodGet, "flickr.photos.getInfo", args, "photo", &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (s *PhotosService) GetSizes(ctx context.Context, photoId string) (*Sizes, error) {
+	args := map[string]string{"photo_id": photoId}
+	var sizes Sizes
+	if err := s.client.call(ctx, http.MethodGet, "flickr.photos.getSizes", args, "sizes", &sizes); err != nil {
+		return nil, err
+	}
+	return &sizes, nil
+}
+
+func (s *PhotosService) GetExif(ctx context.Context, photoId string, secret string) (*PhotoExif, error) {
+	args := map[string]string{"photo_id": photoId}
+	if secret != "" {
+		args["secret"] = secret
+	}
+	var exif PhotoExif
+	if err := s.client.call(ctx, http.MethodGet, "flickr.photos.getExif", args, "photo", &exif); err != nil {
+		return nil, err
+	}
+	return &exif, nil
+}
+
+func (s *PhotosService) SetMeta(ctx context.Context, photoId string, title string, description string) error {
+	args := map[string]string{
+		"photo_id":    photoId,
+		"title":       title,
+		"description": description,
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photos.setMeta", args, "", nil)
+}
+
+// SetTags replaces all tags of a photo.
+func (s *PhotosService) SetTags(ctx context.Context, photoId string, tags []string) error {
+	args := map[string]string{
+		"photo_id": photoId,
+		"tags":     JoinTags(tags),
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photos.setTags", args, "", nil)
+}
+
+func (s *PhotosService) AddTags(ctx context.Context, photoId string, tags []string) error {
+	args := map[string]string{
+		"photo_id": photoId,
+		"tags":     JoinTags(tags),
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photos.addTags", args, "", nil)
+}
+
+// RemoveTag removes a tag by the id found in PhotoInfo.Tags.
+func (s *PhotosService) RemoveTag(ctx context.Context, tagId string) error {
+	args := map[string]string{"tag_id": tagId}
+	return s.client.call(ctx, http.MethodPost, "flickr.photos.removeTag", args, "", nil)
+}
+
+func (s *PhotosService) SetDates(ctx context.Context, photoId string, dates PhotoDates) error {
+	args := map[string]string{"photo_id": photoId}
+	if !dates.Posted.IsZero() {
+		args["date_posted"] = strconv.FormatInt(dates.Posted.Unix(), 10)
+	}
+	if !dates.Taken.IsZero() {
+		args["date_taken"] = dates.Taken.Format(takenDateLayout)
+		args["date_taken_granularity"] = strconv.Itoa(dates.TakenGranularity)
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photos.setDates", args, "", nil)
+}
+
+func (s *PhotosService) SetPerms(ctx context.Context, photoId string, visibility Visibility, permissions Permissions) error {
+	args := map[string]string{
+		"photo_id":     photoId,
+		"is_public":    boolArg(visibility.IsPublic),
+		"is_friend":    boolArg(visibility.IsFriend),
+		"is_family":    boolArg(visibility.IsFamily),
+		"perm_comment": strconv.Itoa(permissions.PermComment),
+		"perm_addmeta": strconv.Itoa(permissions.PermAddMeta),
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photos.setPerms", args, "", nil)
+}
+
+// SetSafetyLevel changes the safety level, unless it is 0, and whether the
+// photo is hidden from public searches, unless hidden is nil.
+func (s *PhotosService) SetSafetyLevel(ctx context.Context, photoId string, safetyLevel int, hidden *bool) error {
+	args := map[string]string{"photo_id": photoId}
+	if safetyLevel > 0 {
+		args["safety_level"] = strconv.Itoa(safetyLevel)
+	}
+	if hidden != nil {
+		args["hidden"] = boolArg(*hidden)
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photos.setSafetyLevel", args, "", nil)
+}
+
+func (s *PhotosService) SetContentType(ctx context.Context, photoId string, contentType int) error {
+	args := map[string]string{
+		"photo_id":     photoId,
+		"content_type": strconv.Itoa(contentType),
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photos.setContentType", args, "", nil)
+}
+
+func (s *PhotosService) Delete(ctx context.Context, photoId string) error {
+	args := map[string]string{"photo_id": photoId}
+	return s.client.call(ctx, http.MethodPost, "flickr.photos.delete", args, "", nil)
+}
+
+// JoinTags formats tags the way Flickr expects them: space separated, with
+// tags that contain spaces quoted.
+func JoinTags(tags []string) string {
+	quoted := make([]string, len(tags))
+	for i, t := range tags {
+		if strings.ContainsAny(t, " \t") {
+			t = `"` + strings.Replace(t, `"`, "", -1) + `"`
+		}
+		quoted[i] = t
+	}
+	return strings.Join(quoted, " ")
+}
+
+func boolArg(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// parseTaken parses the date taken, which Flickr gives in the photo's own
+// unspecified time zone; it is returned as UTC.
+func parseTaken(s string) time.Time {
+	t, err := time.Parse(takenDateLayout, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,88 @@
+package flickr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPhotosService(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.Form.Get("method") {
+		case "flickr.photos.getInfo":
+			fmt.Fprint(w, `<rsp stat="ok"><photo id="2733" secret="123456" server="12" farm="1" isfavorite="0" license="3" rotation="90" originalformat="png" views="5" media="photo" safety_level="0">
+<owner nsid="12037949754@N01" username="Bees" realname="Cal Henderson" location="Bedford, UK" />
+<title>orford_castle_taster</title><description>hello!</description>
+<visibility ispublic="1" isfriend="0" isfamily="0" />
+<dates posted="1100897479" taken="2004-11-19 12:51:19" takengranularity="0" lastupdate="1093022469" />
+<permissions permcomment="3" permaddmeta="2" />
+<comments>1</comments>
+<tags><tag id="1234" author="12037949754@N01" raw="woo yay">wooyay</tag><tag id="1235" author="12037949754@N01" raw="geo:lat=1" machine_tag="1">geo:lat=1</tag></tags>
+<urls><url type="photopage">http://www.flickr.com/photos/bees/2733/</url></urls>
+</photo></rsp>`)
+		case "flickr.photos.getSizes":
+			fmt.Fprint(w, `{"sizes":{"size":[{"label":"Square","width":75,"height":"75","source":"http://example.com/s.jpg","media":"photo"},{"label":"Original","width":"2048","height":1536,"source":"http://example.com/o.jpg","media":"photo"}]},"stat":"ok"}`)
+		case "flickr.photos.getExif":
+			fmt.Fprint(w, `<rsp stat="ok"><photo id="4424" camera="Canon EOS 20D">
+<exif tagspace="TIFF" tagspaceid="1" tag="271" label="Manufacturer"><raw>Canon</raw></exif>
+<exif tagspace="EXIF" tagspaceid="0" tag="33434" label="Exposure"><raw>0.00625</raw><clean>0.006 sec (1/160)</clean></exif>
+</photo></rsp>`)
+		case "flickr.photos.setTags":
+			if r.Form.Get("tags") != `sunset "new york"` {
+				t.Errorf("unexpected tags %q", r.Form.Get("tags"))
+			}
+			fmt.Fprint(w, `<rsp stat="ok"></rsp>`)
+		case "flickr.photos.setDates":
+			if r.Form.Get("date_taken") != "2019-05-01 10:30:00" || r.Form.Get("date_posted") != "" {
+				t.Errorf("unexpected dates %v", r.Form)
+			}
+			fmt.Fprint(w, `<rsp stat="ok"></rsp>`)
+		default:
+			t.Errorf("unexpected method %q", r.Form.Get("method"))
+		}
+	})
+	ctx := context.Background()
+
+	info, err := client.Photos().GetInfo(ctx, "2733", "")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if info.Title != "orford_castle_taster" || info.Owner.Username != "Bees" || !info.Visibility.IsPublic ||
+		info.Permissions.PermComment != PermEveryone || info.Comments != 1 || info.Rotation != 90 ||
+		!info.Taken.Equal(time.Date(2004, 11, 19, 12, 51, 19, 0, time.UTC)) || info.Posted.Unix() != 1100897479 ||
+		len(info.Tags) != 2 || info.Tags[0].Raw != "woo yay" || !info.Tags[1].MachineTag || info.Urls[0].Type != "photopage" {
+		t.Errorf("unexpected info %+v", info)
+	}
+
+	client.Format = FormatJSON
+	sizes, err := client.Photos().GetSizes(ctx, "2733")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if original := sizes.Find("Original"); original == nil || original.Width != 2048 || original.Height != 1536 {
+		t.Errorf("unexpected sizes %+v", sizes)
+	}
+	client.Format = FormatXML
+
+	exif, err := client.Photos().GetExif(ctx, "4424", "")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if v, _ := exif.Find("Exposure"); v != "0.006 sec (1/160)" || exif.Camera != "Canon EOS 20D" {
+		t.Errorf("unexpected exif %+v", exif)
+	}
+	if v, _ := exif.Find("Manufacturer"); v != "Canon" {
+		t.Errorf("unexpected exif %+v", exif)
+	}
+
+	if err := client.Photos().SetTags(ctx, "2733", []string{"sunset", "new york"}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	taken := time.Date(2019, 5, 1, 10, 30, 0, 0, time.UTC)
+	if err := client.Photos().SetDates(ctx, "2733", PhotoDates{Taken: taken}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+}
@@ -0,0 +1,312 @@
+package flickr
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Photoset struct {
+	Id            string
+	Owner         string
+	OwnerName     string
+	Title         string
+	Description   string
+	Primary       string
+	Secret        string
+	Server        string
+	Farm          int
+	Url           string
+	PhotoCount    int
+	VideoCount    int
+	CountViews    int
+	CountComments int
+	DateCreate    time.Time
+	DateUpdate    time.Time
+	Photo         []Photo
+	Page          int
+	PerPage       int
+	Pages         int
+	Total         int
+}
+
+// photosetFields is the wire form of a photoset, shared by XML and JSON.
+// getList and getInfo send title and description as elements, getPhotos
+// sends the title as an attribute.
+type photosetFields struct {
+	Id            string     `xml:"id,attr" json:"id"`
+	Owner         string     `xml:"owner,attr" json:"owner"`
+	OwnerName     string     `xml:"ownername,attr" json:"ownername"`
+	Username      string     `xml:"username,attr" json:"username"`
+	Title         flexString `xml:"title" json:"title"`
+	TitleAttr     string     `xml:"title,attr" json:"-"`
+	Description   flexString `xml:"description" json:"description"`
+	Primary       string     `xml:"primary,attr" json:"primary"`
+	Secret        string     `xml:"secret,attr" json:"secret"`
+	Server        string     `xml:"server,attr" json:"server"`
+	Farm          flexInt    `xml:"farm,attr" json:"farm"`
+	Url           string     `xml:"url,attr" json:"url"`
+	Photos        flexInt    `xml:"photos,attr" json:"photos"`
+	Videos        flexInt    `xml:"videos,attr" json:"videos"`
+	CountViews    flexInt    `xml:"count_views,attr" json:"count_views"`
+	CountComments flexInt    `xml:"count_comments,attr" json:"count_comments"`
+	DateCreate    flexString `xml:"date_create,attr" json:"date_create"`
+	DateUpdate    flexString `xml:"date_update,attr" json:"date_update"`
+	Photo         []Photo    `xml:"photo" json:"photo"`
+	Page          flexInt    `xml:"page,attr" json:"page"`
+	PerPage       flexInt    `xml:"perpage,attr" json:"perpage"`
+	Pages         flexInt    `xml:"pages,attr" json:"pages"`
+	Total         flexInt    `xml:"total,attr" json:"total"`
+}
+
+func (f *photosetFields) photoset() Photoset {
+	p := Photoset{
+		Id:            f.Id,
+		Owner:         f.Owner,
+		OwnerName:     f.OwnerName,
+		Title:         string(f.Title),
+		Description:   string(f.Description),
+		Primary:       f.Primary,
+		Secret:        f.Secret,
+		Server:        f.Server,
+		Farm:          int(f.Farm),
+		Url:           f.Url,
+		PhotoCount:    int(f.Photos),
+		VideoCount:    int(f.Videos),
+		CountViews:    int(f.CountViews),
+		CountComments: int(f.CountComments),
+		DateCreate:    parseUnix(string(f.DateCreate)),
+		DateUpdate:    parseUnix(string(f.DateUpdate)),
+		Photo:         f.Photo,
+		Page:          int(f.Page),
+		PerPage:       int(f.PerPage),
+		Pages:         int(f.Pages),
+		Total:         int(f.Total),
+	}
+	if p.Title == "" {
+		p.Title = f.TitleAttr
+	}
+	if p.OwnerName == "" {
+		p.OwnerName = f.Username
+	}
+	return p
+}
+
+func (p *Photoset) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var f photosetFields
+	if err := d.DecodeElement(&f, &start); err != nil {
+		return err
+	}
+	*p = f.photoset()
+	return nil
+}
+
+func (p *Photoset) UnmarshalJSON(b []byte) error {
+	var f photosetFields
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+	*p = f.photoset()
+	return nil
+}
+
+type Photosets struct {
+	Photoset []Photoset `xml:"photoset" json:"photoset"`
+	Page     int        `xml:"page,attr" json:"-"`
+	Pages    int        `xml:"pages,attr" json:"-"`
+	PerPage  int        `xml:"perpage,attr" json:"-"`
+	Total    int        `xml:"total,attr" json:"-"`
+}
+
+func (p *Photosets) UnmarshalJSON(b []byte) error {
+	var f struct {
+		Photoset []Photoset `json:"photoset"`
+		Page     flexInt    `json:"page"`
+		Pages    flexInt    `json:"pages"`
+		PerPage  flexInt    `json:"perpage"`
+		Total    flexInt    `json:"total"`
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+	*p = Photosets{f.Photoset, int(f.Page), int(f.Pages), int(f.PerPage), int(f.Total)}
+	return nil
+}
+
+// PageOptions selects a page of a paged list method. Zero values leave the
+// choice to Flickr.
+type PageOptions struct {
+	Page    int
+	PerPage int
+}
+
+func (o *PageOptions) addArgs(args map[string]string) {
+	if o == nil {
+		return
+	}
+	if o.Page > 0 {
+		args["page"] = strconv.Itoa(o.Page)
+	}
+	if o.PerPage > 0 {
+		args["per_page"] = strconv.Itoa(o.PerPage)
+	}
+}
+
+type PhotosetPhotosOptions struct {
+	PageOptions
+	UserId        string
+	Extras        Extras
+	PrivacyFilter int
+	Media         string
+}
+
+// PhotosetsService wraps the flickr.photosets.* methods.
+type PhotosetsService struct {
+	client *Client
+}
+
+func (c *Client) Photosets() *PhotosetsService {
+	return &PhotosetsService{c}
+}
+
+// GetList returns the photosets of userId, or of the calling user when empty.
+func (s *PhotosetsService) GetList(ctx context.Context, userId string, opts *PageOptions) (*Photosets, error) {
+	args := make(map[string]string)
+	if userId != "" {
+		args["user_id"] = userId
+	}
+	opts.addArgs(args)
+	var photosets Photosets
+	if err := s.client.call(ctx, http.MethodGet, "flickr.photosets.getList", args, "photosets", &photosets); err != nil {
+		return nil, err
+	}
+	return &photosets, nil
+}
+
+func (s *PhotosetsService) GetInfo(ctx context.Context, photosetId string) (*Photoset, error) {
+	args := map[string]string{"photoset_id": photosetId}
+	var photoset Photoset
+	if err := s.client.call(ctx, http.MethodGet, "flickr.photosets.getInfo", args, "photoset", &photoset); err != nil {
+		return nil, err
+	}
+	return &photoset, nil
+}
+
+func (s *PhotosetsService) GetPhotos(ctx context.Context, photosetId string, opts *PhotosetPhotosOptions) (*Photoset, error) {
+	args := map[string]string{"photoset_id": photosetId}
+	if opts != nil {
+		opts.PageOptions.addArgs(args)
+		if opts.UserId != "" {
+			args["user_id"] = opts.UserId
+		}
+		if len(opts.Extras) > 0 {
+			args["extras"] = opts.Extras.String()
+		}
+		if opts.PrivacyFilter > 0 {
+			args["privacy_filter"] = strconv.Itoa(opts.PrivacyFilter)
+		}
+		if opts.Media != "" {
+			args["media"] = opts.Media
+		}
+	}
+	var photoset Photoset
+	if err := s.client.call(ctx, http.MethodGet, "flickr.photosets.getPhotos", args, "photoset", &photoset); err != nil {
+		return nil, err
+	}
+	return &photoset, nil
+}
+
+// Create makes a new photoset. Flickr only answers with its id and url, the
+// other fields are filled in from the arguments.
+func (s *PhotosetsService) Create(ctx context.Context, title string, description string, primaryPhotoId string) (*Photoset, error) {
+	args := map[string]string{
+		"title":            title,
+		"primary_photo_id": primaryPhotoId,
+	}
+	if description != "" {
+		args["description"] = description
+	}
+	var photoset Photoset
+	if err := s.client.call(ctx, http.MethodPost, "flickr.photosets.create", args, "photoset", &photoset); err != nil {
+		return nil, err
+	}
+	photoset.Title = title
+	photoset.Description = description
+	photoset.Primary = primaryPhotoId
+	return &photoset, nil
+}
+
+func (s *PhotosetsService) Delete(ctx context.Context, photosetId string) error {
+	args := map[string]string{"photoset_id": photosetId}
+	return s.client.call(ctx, http.MethodPost, "flickr.photosets.delete", args, "", nil)
+}
+
+func (s *PhotosetsService) EditMeta(ctx context.Context, photosetId string, title string, description string) error {
+	args := map[string]string{
+		"photoset_id": photosetId,
+		"title":       title,
+		"description": description,
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photosets.editMeta", args, "", nil)
+}
+
+// EditPhotos replaces the photos of a photoset; photoIds must contain the
+// primary photo.
+func (s *PhotosetsService) EditPhotos(ctx context.Context, photosetId string, primaryPhotoId string, photoIds []string) error {
+	args := map[string]string{
+		"photoset_id":      photosetId,
+		"primary_photo_id": primaryPhotoId,
+		"photo_ids":        strings.Join(photoIds, ","),
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photosets.editPhotos", args, "", nil)
+}
+
+func (s *PhotosetsService) AddPhoto(ctx context.Context, photosetId string, photoId string) error {
+	args := map[string]string{
+		"photoset_id": photosetId,
+		"photo_id":    photoId,
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photosets.addPhoto", args, "", nil)
+}
+
+func (s *PhotosetsService) RemovePhoto(ctx context.Context, photosetId string, photoId string) error {
+	args := map[string]string{
+		"photoset_id": photosetId,
+		"photo_id":    photoId,
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photosets.removePhoto", args, "", nil)
+}
+
+func (s *PhotosetsService) RemovePhotos(ctx context.Context, photosetId string, photoIds []string) error {
+	args := map[string]string{
+		"photoset_id": photosetId,
+		"photo_ids":   strings.Join(photoIds, ","),
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photosets.removePhotos", args, "", nil)
+}
+
+func (s *PhotosetsService) ReorderPhotos(ctx context.Context, photosetId string, photoIds []string) error {
+	args := map[string]string{
+		"photoset_id": photosetId,
+		"photo_ids":   strings.Join(photoIds, ","),
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photosets.reorderPhotos", args, "", nil)
+}
+
+func (s *PhotosetsService) SetPrimaryPhoto(ctx context.Context, photosetId string, photoId string) error {
+	args := map[string]string{
+		"photoset_id": photosetId,
+		"photo_id":    photoId,
+	}
+	return s.client.call(ctx, http.MethodPost, "flickr.photosets.setPrimaryPhoto", args, "", nil)
+}
+
+// OrderSets sets the order of the calling user's photosets.
+func (s *PhotosetsService) OrderSets(ctx context.Context, photosetIds []string) error {
+	args := map[string]string{"photoset_ids": strings.Join(photosetIds, ",")}
+	return s.client.call(ctx, http.MethodPost, "flickr.photosets.orderSets", args, "", nil)
+}
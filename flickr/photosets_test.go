@@ -0,0 +1,77 @@
+package flickr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := NewClient(auth, secret)
+	client.APIEndpoint = server.URL
+	client.UploadEndpoint = server.URL + "/upload"
+	client.ReplaceEndpoint = server.URL + "/replace"
+	return client
+}
+
+func TestPhotosetsService(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.Form.Get("method") {
+		case "flickr.photosets.getList":
+			fmt.Fprint(w, `<rsp stat="ok"><photosets page="1" pages="1" perpage="500" total="1">
+<photoset id="72157" primary="2483" secret="abc" server="8" farm="1" photos="4" videos="1" count_views="7" date_create="1530000000">
+<title>Trip</title><description>Summer</description></photoset></photosets></rsp>`)
+		case "flickr.photosets.getPhotos":
+			if r.Form.Get("page") != "2" || r.Form.Get("extras") != "url_o" {
+				t.Errorf("unexpected args %v", r.Form)
+			}
+			fmt.Fprint(w, `<rsp stat="ok"><photoset id="72157" title="Trip" page="2" pages="3" perpage="1" total="3">
+<photo id="1" title="a" url_o="http://example.com/a.jpg" originalformat="jpg" /></photoset></rsp>`)
+		case "flickr.photosets.create":
+			if r.Method != http.MethodPost {
+				t.Errorf("create sent as %s", r.Method)
+			}
+			fmt.Fprint(w, `<rsp stat="ok"><photoset id="99" url="http://example.com/sets/99/" /></rsp>`)
+		case "flickr.photosets.addPhoto":
+			fmt.Fprint(w, `<rsp stat="fail"><err code="3" msg="Photo already in set" /></rsp>`)
+		default:
+			t.Errorf("unexpected method %q", r.Form.Get("method"))
+		}
+	})
+	ctx := context.Background()
+
+	list, err := client.Photosets().GetList(ctx, "", nil)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	set := list.Photoset[0]
+	if list.Total != 1 || set.Title != "Trip" || set.Description != "Summer" || set.PhotoCount != 4 ||
+		set.Farm != 1 || set.DateCreate.Unix() != 1530000000 {
+		t.Errorf("unexpected list %+v", list)
+	}
+
+	photos, err := client.Photosets().GetPhotos(ctx, "72157", &PhotosetPhotosOptions{PageOptions: PageOptions{Page: 2}, Extras: Extras{ExtraURLO}})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if photos.Title != "Trip" || photos.Pages != 3 || len(photos.Photo) != 1 || photos.Photo[0].UrlO != "http://example.com/a.jpg" {
+		t.Errorf("unexpected photos %+v", photos)
+	}
+
+	created, err := client.Photosets().Create(ctx, "New", "", "1")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if created.Id != "99" || created.Url != "http://example.com/sets/99/" || created.Title != "New" {
+		t.Errorf("unexpected photoset %+v", created)
+	}
+
+	if err := client.Photosets().AddPhoto(ctx, "99", "1"); !IsFlickrError(err, 3) {
+		t.Errorf("expected code 3, got %+v", err)
+	}
+}
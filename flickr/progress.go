@@ -0,0 +1,73 @@
+package flickr
+
+import (
+	"io"
+	"time"
+)
+
+// progressInterval is the least time between two progress reports, except
+// for the final one.
+const progressInterval = 200 * time.Millisecond
+
+// Progress is the state of a running upload.
+type Progress struct {
+	// Name is the file name the upload is sent under.
+	Name string
+	// Sent counts the bytes of the file sent so far, Total is its size or -1
+	// when unknown.
+	Sent    int64
+	Total   int64
+	Elapsed time.Duration
+	// Rate is the average number of bytes sent per second.
+	Rate float64
+	// Done is set on the last report of an upload.
+	Done bool
+}
+
+// ETA estimates the time left, or returns -1 when it cannot be told yet.
+func (p Progress) ETA() time.Duration {
+	if p.Total < 0 || p.Rate <= 0 {
+		return -1
+	}
+	return time.Duration(float64(p.Total-p.Sent) / p.Rate * float64(time.Second))
+}
+
+// ProgressFunc is called from the goroutine sending the upload.
+type ProgressFunc func(Progress)
+
+// SetProgress makes a later Upload, UploadReader or Replace report to fn.
+func (request *Request) SetProgress(fn ProgressFunc) {
+	request.progress = fn
+}
+
+// progressReader reports the bytes read through it.
+type progressReader struct {
+	r        io.Reader
+	fn       ProgressFunc
+	progress Progress
+	start    time.Time
+	last     time.Time
+}
+
+func newProgressReader(r io.Reader, name string, size int64, fn ProgressFunc) *progressReader {
+	now := time.Now()
+	return &progressReader{r: r, fn: fn, progress: Progress{Name: name, Total: size}, start: now, last: now}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.progress.Sent += int64(n)
+	now := time.Now()
+	if err == io.EOF {
+		p.progress.Done = true
+	}
+	if p.progress.Done || now.Sub(p.last) >= progressInterval {
+		p.last = now
+		p.progress.Elapsed = now.Sub(p.start)
+		if seconds := p.progress.Elapsed.Seconds(); seconds > 0 {
+			p.progress.Rate = float64(p.progress.Sent) / seconds
+		}
+		p.fn(p.progress)
+	}
+	return n, err
+}
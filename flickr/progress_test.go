@@ -0,0 +1,50 @@
+package flickr
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUploadProgress(t *testing.T) {
+	content := append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, make([]byte, 1<<20)...)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		fmt.Fprint(w, `<rsp stat="ok"><photoid>1</photoid></rsp>`)
+	})
+
+	var reports []Progress
+	request := client.NewRequest(http.MethodPost, nil)
+	request.SetProgress(func(p Progress) {
+		reports = append(reports, p)
+	})
+	if _, err := request.UploadReader("big.jpg", bytes.NewReader(content), "", int64(len(content))); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(reports) == 0 {
+		t.Fatal("no progress reported")
+	}
+	last := reports[len(reports)-1]
+	if !last.Done || last.Name != "big.jpg" || last.Sent != int64(len(content)) || last.Total != last.Sent || last.ETA() != 0 {
+		t.Errorf("unexpected final report %+v", last)
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i].Sent < reports[i-1].Sent || reports[i-1].Done {
+			t.Errorf("report %d out of order: %+v", i, reports)
+		}
+	}
+}
+
+func TestProgressETA(t *testing.T) {
+	p := Progress{Sent: 100, Total: 400, Rate: 100}
+	if eta := p.ETA(); eta != 3*time.Second {
+		t.Errorf("got %s", eta)
+	}
+	p.Total = -1
+	if eta := p.ETA(); eta != -1 {
+		t.Errorf("unknown total: got %s", eta)
+	}
+}
@@ -0,0 +1,217 @@
+package flickr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultCallsPerHour is the quota Flickr grants an API key.
+const DefaultCallsPerHour = 3600
+
+// TokenBucket holds up to Burst tokens and refills them at Rate per second.
+// It is safe for concurrent use.
+type TokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	used   int64
+	now    func() time.Time
+}
+
+// NewTokenBucket returns a full bucket. It panics if rate is not positive.
+func NewTokenBucket(rate float64, burst float64) *TokenBucket {
+	if !(rate > 0) {
+		panic(errors.New("Non-positive rate for TokenBucket"))
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{rate: rate, burst: burst, tokens: burst, now: time.Now}
+}
+
+// refill adds the tokens earned since the last call; b.mu must be held.
+func (b *TokenBucket) refill() {
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
+
+// Take removes n tokens if they are there and reports whether it did.
+func (b *TokenBucket) Take(n int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+	b.used += n
+	return true
+}
+
+// Wait removes n tokens, blocking until they are earned or ctx is done.
+// Waiters are served in order since each one reserves its tokens up front.
+func (b *TokenBucket) Wait(ctx context.Context, n int64) error {
+	b.mu.Lock()
+	b.refill()
+	b.tokens -= float64(n)
+	b.used += n
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens += float64(n)
+		b.used -= n
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// BucketUsage is a snapshot of a TokenBucket.
+type BucketUsage struct {
+	// Used counts the tokens taken since the bucket was made.
+	Used int64
+	// Available is the number of tokens that can be taken right now; it is
+	// negative while waiters are queued.
+	Available float64
+	Burst     float64
+	Rate      float64
+}
+
+func (b *TokenBucket) Usage() BucketUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	return BucketUsage{Used: b.used, Available: b.tokens, Burst: b.burst, Rate: b.rate}
+}
+
+// RateLimiter keeps a client within its quota. Every API call, upload and
+// replace takes a token from Calls, and every uploaded byte one from
+// UploadBytes; a nil bucket is not limited.
+type RateLimiter struct {
+	Calls       *TokenBucket
+	UploadBytes *TokenBucket
+	// FailFast makes calls over the budget fail with ErrRateLimited instead
+	// of waiting for it to refill.
+	FailFast bool
+}
+
+// NewRateLimiter allows callsPerHour calls, in bursts of up to a minute's
+// worth, and uploadBytesPerSecond bytes. Either is unlimited when 0.
+func NewRateLimiter(callsPerHour int, uploadBytesPerSecond int64) *RateLimiter {
+	l := &RateLimiter{}
+	if callsPerHour > 0 {
+		l.Calls = NewTokenBucket(float64(callsPerHour)/3600, float64(callsPerHour)/60)
+	}
+	if uploadBytesPerSecond > 0 {
+		l.UploadBytes = NewTokenBucket(float64(uploadBytesPerSecond), float64(uploadBytesPerSecond))
+	}
+	return l
+}
+
+// RateUsage is a snapshot of the budgets of a RateLimiter.
+type RateUsage struct {
+	Calls       BucketUsage
+	UploadBytes BucketUsage
+}
+
+func (l *RateLimiter) Usage() RateUsage {
+	var usage RateUsage
+	if l.Calls != nil {
+		usage.Calls = l.Calls.Usage()
+	}
+	if l.UploadBytes != nil {
+		usage.UploadBytes = l.UploadBytes.Usage()
+	}
+	return usage
+}
+
+// call takes the token for one call.
+func (l *RateLimiter) call(ctx context.Context) error {
+	if l == nil || l.Calls == nil {
+		return nil
+	}
+	if l.FailFast {
+		if !l.Calls.Take(1) {
+			return ErrRateLimited
+		}
+		return nil
+	}
+	return l.Calls.Wait(ctx, 1)
+}
+
+// checkUpload fails in FailFast mode when the upload byte budget cannot
+// take a body of size bytes right away: all of it, or a full burst for
+// bodies bigger than that or of unknown size. A body that has started is
+// never cut off, it waits for its budget instead.
+func (l *RateLimiter) checkUpload(size int64) error {
+	if l == nil || l.UploadBytes == nil || !l.FailFast {
+		return nil
+	}
+	usage := l.UploadBytes.Usage()
+	need := usage.Burst
+	if size >= 0 && float64(size) < need {
+		need = float64(size)
+	}
+	if usage.Available < need {
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// uploadReader throttles r to the upload byte budget.
+func (l *RateLimiter) uploadReader(ctx context.Context, r io.Reader) io.Reader {
+	if l == nil || l.UploadBytes == nil {
+		return r
+	}
+	return &limitedReader{ctx: ctx, r: r, limiter: l}
+}
+
+type limitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *RateLimiter
+}
+
+func (r *limitedReader) Read(b []byte) (int, error) {
+	bucket := r.limiter.UploadBytes
+	n := int64(len(b))
+	if burst := int64(bucket.burst); n > burst {
+		n = burst
+	}
+	if err := bucket.Wait(r.ctx, n); err != nil {
+		return 0, err
+	}
+	read, err := r.r.Read(b[:n])
+	// Hand back what was not read
+	if unread := n - int64(read); unread > 0 {
+		bucket.mu.Lock()
+		bucket.tokens += float64(unread)
+		bucket.used -= unread
+		bucket.mu.Unlock()
+	}
+	return read, err
+}
@@ -0,0 +1,122 @@
+package flickr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(1000, 0)
+	bucket := NewTokenBucket(2, 4)
+	bucket.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		if !bucket.Take(1) {
+			t.Fatalf("take %d failed on a full bucket", i)
+		}
+	}
+	if bucket.Take(1) {
+		t.Error("took from an empty bucket")
+	}
+	now = now.Add(time.Second)
+	if !bucket.Take(2) || bucket.Take(1) {
+		t.Error("expected exactly 2 tokens after a second")
+	}
+	now = now.Add(time.Hour)
+	if usage := bucket.Usage(); usage.Available != 4 || usage.Used != 6 || usage.Rate != 2 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := bucket.Wait(ctx, 10); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if usage := bucket.Usage(); usage.Available != 4 || usage.Used != 6 {
+		t.Errorf("cancelled wait kept its tokens: %+v", usage)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		calls++
+		fmt.Fprint(w, `<rsp stat="ok"><photoid>1</photoid></rsp>`)
+	})
+	client.RateLimiter = NewRateLimiter(120, 0)
+	client.RateLimiter.FailFast = true
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := client.Photos().Delete(ctx, "1"); err != nil {
+			t.Fatalf("call %d: %+v", i, err)
+		}
+	}
+	if err := client.Photos().Delete(ctx, "1"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("%d calls reached the server", calls)
+	}
+
+	// Waiting for the next token takes half a minute at 120 calls an hour
+	client.RateLimiter.FailFast = false
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := client.Photos().Delete(timeout, "1"); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	client.RateLimiter = NewRateLimiter(0, 64<<10)
+	content := append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, make([]byte, 96<<10)...)
+	start := time.Now()
+	if _, err := client.NewRequest(http.MethodPost, nil).UploadReader("a.jpg", bytes.NewReader(content), "", int64(len(content))); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("96KiB at 64KiB/s took only %s", elapsed)
+	}
+	if usage := client.RateLimiter.Usage(); usage.UploadBytes.Used != int64(len(content)) || usage.Calls != (BucketUsage{}) {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+
+	// Fail fast checks the budget before sending and then waits for it,
+	// rather than cutting a body off halfway
+	client.RateLimiter = NewRateLimiter(0, 64<<10)
+	client.RateLimiter.FailFast = true
+	calls = 0
+	start = time.Now()
+	if _, err := client.NewRequest(http.MethodPost, nil).UploadReader("a.jpg", bytes.NewReader(content), "", int64(len(content))); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("96KiB at 64KiB/s took only %s", elapsed)
+	}
+	_, err := client.NewRequest(http.MethodPost, nil).UploadReader("a.jpg", bytes.NewReader(content), "", int64(len(content)))
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("%d uploads reached the server", calls)
+	}
+}
+
+func TestNewTokenBucketRate(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("rate %v did not panic", rate)
+				}
+			}()
+			NewTokenBucket(rate, 1)
+		}()
+	}
+}
@@ -0,0 +1,124 @@
+package flickr
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newFixtureClient returns a client replaying testdata/name.json. With
+// FLICKR_RECORD=1 it records the fixture afresh against Flickr instead,
+// using the credentials in FLICKR_CONSUMER_KEY, FLICKR_TOKEN and
+// FLICKR_SECRET.
+func newFixtureClient(t *testing.T, name string) *Client {
+	fixture := filepath.Join("testdata", name+".json")
+	mode := ModeReplay
+	client := NewClient(map[string]string{"oauth_token": "token", "oauth_consumer_key": "key"}, SigningKey("consumer", "token-secret"))
+	if os.Getenv("FLICKR_RECORD") == "1" {
+		mode = ModeRecord
+		client = NewClient(map[string]string{
+			"oauth_token":        os.Getenv("FLICKR_TOKEN"),
+			"oauth_consumer_key": os.Getenv("FLICKR_CONSUMER_KEY"),
+		}, os.Getenv("FLICKR_SECRET"))
+	}
+	recorder, err := NewRecorder(fixture, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: recorder}
+	return client
+}
+
+func TestRecorder(t *testing.T) {
+	photo := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x80}
+	calls := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/photo.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write(photo)
+		case "/upload":
+			fmt.Fprint(w, `<rsp stat="ok"><photoid>7</photoid></rsp>`)
+		default:
+			if calls == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, `<rsp stat="ok"><method>flickr.test.echo</method><token>oauth_token=abc&amp;oauth_token_secret=def</token></rsp>`)
+		}
+	})
+	client.Auth = map[string]string{"oauth_token": "secret-token", "oauth_consumer_key": "key"}
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+	recorder, err := NewRecorder(fixture, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: recorder}
+
+	flow := func(client *Client) {
+		t.Helper()
+		echo := map[string]string{"method": "flickr.test.echo", "name": "a b"}
+		if _, err := client.NewRequest(http.MethodGet, echo).Execute(); !IsRetryable(err) {
+			t.Errorf("expected a retryable failure, got %+v", err)
+		}
+		response, err := client.NewRequest(http.MethodGet, echo).Execute()
+		if err != nil || !strings.Contains(response, "flickr.test.echo") {
+			t.Errorf("unexpected echo %q, %v", response, err)
+		}
+		if photoId, err := client.NewRequest(http.MethodPost, map[string]string{"title": "t"}).Upload(writeTestJpeg(t)); err != nil || photoId != "7" {
+			t.Errorf("unexpected upload %q, %v", photoId, err)
+		}
+		resp, err := client.HTTPClient.Get(strings.TrimSuffix(client.UploadEndpoint, "/upload") + "/photo.jpg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if body, _ := io.ReadAll(resp.Body); !bytes.Equal(body, photo) || resp.Header.Get("Content-Type") != "image/jpeg" {
+			t.Errorf("unexpected download %v %v", body, resp.Header)
+		}
+	}
+	flow(client)
+
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{"secret-token", "oauth_token=abc", "def"} {
+		if strings.Contains(string(data), leak) {
+			t.Errorf("fixture leaks %q:\n%s", leak, data)
+		}
+	}
+	interactions := recorder.Interactions()
+	if len(interactions) != 4 || interactions[0].Status != http.StatusServiceUnavailable || interactions[0].Header["Retry-After"] != "1" {
+		t.Fatalf("unexpected interactions %+v", interactions)
+	}
+	if upload := interactions[2].Args; upload["photo"] != "photo.jpg" || upload["title"] != "t" || upload["oauth_nonce"] != redacted {
+		t.Errorf("unexpected upload args %v", upload)
+	}
+	if interactions[3].Encoding != "base64" {
+		t.Errorf("binary body recorded as %q", interactions[3].Encoding)
+	}
+
+	// Replay without the server, under other credentials and fresh nonces
+	recorder, err = NewRecorder(fixture, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls = -100
+	client.Auth = map[string]string{"oauth_token": "other-token", "oauth_consumer_key": "key"}
+	client.HTTPClient = &http.Client{Transport: recorder}
+	flow(client)
+	if calls != -100 {
+		t.Error("replay reached the server")
+	}
+	if _, err := client.NewRequest(http.MethodGet, map[string]string{"method": "flickr.test.login"}).Execute(); err == nil || !strings.Contains(err.Error(), "No recorded interaction") {
+		t.Errorf("expected an unmatched request to fail, got %v", err)
+	}
+}
@@ -1,10 +1,12 @@
 package flickr
 
 import (
+	"context"
 	"errors"
 	"flag"
-	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 )
@@ -17,19 +19,44 @@ type RequestTemplate struct {
 	Dir            string
 	Collection     string
 	Album          string
+	// CallsPerHour and UploadRate are the budget of the client, UploadRate
+	// in bytes per second; 0 means unlimited.
+	CallsPerHour int
+	UploadRate   int64
+	// CacheDir, if set, keeps read-only results on disk between runs.
+	CacheDir string
+	// Logger writes the diagnostics to stderr in the chosen format. NewClient
+	// hands it to the client; commands that want CheckErr to use it too
+	// make it the slog default.
+	Logger *slog.Logger
+	// Recorder, if set, records the run into a fixture or replays one.
+	Recorder *Recorder
 }
 
 func NewRequestFromCmd() (*RequestTemplate, error) {
-	var httpMethod, oauth_consumer_key, oauth_token, args, secret, dir, collection, album string
+	var httpMethod, oauth_consumer_key, oauth_token, args, secret, dir, collection, album, cacheDir, logFormat, logLevel, record, replay string
+	var callsPerHour int
+	var uploadRate int64
 	flag.StringVar(&httpMethod, "http_method", http.MethodGet, "The HTTP verb this request should use.")
 	flag.StringVar(&oauth_consumer_key, "oauth_consumer_key", "", "The API Key flickr gives.")
 	flag.StringVar(&oauth_token, "oauth_token", "", "The oauth token.")
 	flag.StringVar(&args, "args", "", "Only for non-upload or non-replace. Arguments like flickr method, photo_id, etc. Format: \"key1=value1&key2=value2...\".")
 	flag.StringVar(&secret, "secret", "", "The secret used to sign the request composed by \"api_secret&token_secret\".")
 	flag.StringVar(&dir, "dir", "", "Only for upload request. Cannot be used together with `args`. The directory of photos to be uploaded.")
-	flag.StringVar(&collection, "collection", "", "Optional. Only for upload request. The collection the album should be put in.")
+	flag.StringVar(&collection, "collection", "", "Optional. Only for upload request. The collection the album should be put in. Nested collections are given as a path like \"Travel/2019\", missing ones are created.")
 	flag.StringVar(&album, "album", "", "Optional. Only for upload request. The album name to upload into. If not exsiting a new album will be created. Note: files with duplicate name in the album will be skipped.")
+	flag.IntVar(&callsPerHour, "calls_per_hour", DefaultCallsPerHour, "Optional. The most API calls to make per hour, 0 for no limit. Calls wait when the budget is used up.")
+	flag.Int64Var(&uploadRate, "upload_rate", 0, "Optional. The most bytes per second to upload, 0 for no limit.")
+	flag.StringVar(&cacheDir, "cache_dir", "", "Optional. Directory to cache album lists, photo lists and collection trees in between runs.")
+	flag.StringVar(&logFormat, "log_format", "text", "Optional. \"text\" or \"json\" diagnostics on stderr.")
+	flag.StringVar(&logLevel, "log_level", "info", "Optional. \"debug\" to log every API call, \"info\", \"warn\" or \"error\".")
+	flag.StringVar(&record, "record", "", "Optional. Fixture file to record the API interactions of this run into, credentials scrubbed.")
+	flag.StringVar(&replay, "replay", "", "Optional. Fixture file recorded with `record` to replay instead of calling Flickr.")
 	flag.Parse()
+	logger, err := NewLogger(os.Stderr, logFormat, logLevel)
+	if err != nil {
+		return nil, err
+	}
 	if oauth_consumer_key == "" {
 		return nil, errors.New("Missing oauth_consumer_key")
 	}
@@ -39,6 +66,18 @@ func NewRequestFromCmd() (*RequestTemplate, error) {
 	if secret == "" {
 		return nil, errors.New("Missing secret")
 	}
+	if record != "" && replay != "" {
+		return nil, errors.New("Either record or replay can be taken")
+	}
+	var recorder *Recorder
+	if record != "" {
+		recorder, err = NewRecorder(record, ModeRecord)
+	} else if replay != "" {
+		recorder, err = NewRecorder(replay, ModeReplay)
+	}
+	if err != nil {
+		return nil, err
+	}
 	if args != "" && (dir != "" || collection != "" || album != "") {
 		return nil, errors.New("Either args or dir [+ collection] [+ album] can be taken")
 	}
@@ -57,38 +96,103 @@ func NewRequestFromCmd() (*RequestTemplate, error) {
 		}
 	}
 	return &RequestTemplate{
-		httpMethod, auth, additionalArgs, secret, dir, collection, album,
+		httpMethod, auth, additionalArgs, secret, dir, collection, album, callsPerHour, uploadRate, cacheDir, logger, recorder,
 	}, nil
 }
 
-func retry(attempts int, sleep time.Duration, fn func() error) error {
-	if err := fn(); err != nil {
-		if attempts--; attempts > 0 {
-			fmt.Printf("Retrying after %s...\n", sleep)
-			time.Sleep(sleep)
-			return retry(attempts, 2*sleep, fn)
-		}
-		return err
+// NewClient returns a client for the template's credentials that retries
+// with DefaultRetryPolicy and logs to the template's logger.
+func (requestTemplate *RequestTemplate) NewClient() *Client {
+	client := NewClient(requestTemplate.Auth, requestTemplate.Secret)
+	policy := *DefaultRetryPolicy
+	client.RetryPolicy = &policy
+	client.Logger = requestTemplate.Logger
+	if requestTemplate.CallsPerHour > 0 || requestTemplate.UploadRate > 0 {
+		client.RateLimiter = NewRateLimiter(requestTemplate.CallsPerHour, requestTemplate.UploadRate)
+	}
+	if requestTemplate.Recorder != nil {
+		client.HTTPClient = &http.Client{Transport: requestTemplate.Recorder}
 	}
-	return nil
+	if requestTemplate.CacheDir != "" {
+		client.Cache = NewDiskCache(requestTemplate.CacheDir)
+	}
+	return client
 }
 
+// ExecuteWithRetry executes the request up to attempts times, waiting sleep
+// and then twice as long each time between attempts that failed for a
+// retryable reason.
 func (request *Request) ExecuteWithRetry(attempts int, sleep time.Duration) (string, error) {
+	return request.ExecuteWithRetryContext(context.Background(), attempts, sleep)
+}
+
+func (request *Request) ExecuteWithRetryContext(ctx context.Context, attempts int, sleep time.Duration) (string, error) {
+	return request.ExecuteWithPolicyContext(ctx, &RetryPolicy{MaxAttempts: attempts, BaseDelay: sleep})
+}
+
+// ExecuteWithPolicy executes the request under policy; nil means
+// DefaultRetryPolicy.
+func (request *Request) ExecuteWithPolicy(policy *RetryPolicy) (string, error) {
+	return request.ExecuteWithPolicyContext(context.Background(), policy)
+}
+
+func (request *Request) ExecuteWithPolicyContext(ctx context.Context, policy *RetryPolicy) (string, error) {
 	var response string
-	retryErr := retry(attempts, sleep, func() error {
+	retryErr := request.retry(ctx, policy, func() error {
 		var err error
-		response, err = request.Execute()
+		response, err = request.ExecuteContext(ctx)
 		return err
 	})
 	return response, retryErr
 }
 
 func (request *Request) UploadWithRetry(photoPath string, attempts int, sleep time.Duration) (string, error) {
+	return request.UploadWithRetryContext(context.Background(), photoPath, attempts, sleep)
+}
+
+func (request *Request) UploadWithRetryContext(ctx context.Context, photoPath string, attempts int, sleep time.Duration) (string, error) {
+	return request.UploadWithPolicyContext(ctx, photoPath, &RetryPolicy{MaxAttempts: attempts, BaseDelay: sleep})
+}
+
+func (request *Request) UploadWithPolicy(photoPath string, policy *RetryPolicy) (string, error) {
+	return request.UploadWithPolicyContext(context.Background(), photoPath, policy)
+}
+
+func (request *Request) UploadWithPolicyContext(ctx context.Context, photoPath string, policy *RetryPolicy) (string, error) {
 	var photoId string
-	retryErr := retry(attempts, sleep, func() error {
+	retryErr := request.retry(ctx, policy, func() error {
 		var err error
-		photoId, err = request.Upload(photoPath)
+		photoId, err = request.UploadContext(ctx, photoPath)
 		return err
 	})
 	return photoId, retryErr
 }
+
+func (request *Request) ReplaceWithRetry(photoId string, photoPath string, attempts int, sleep time.Duration) (*ReplaceResult, error) {
+	return request.ReplaceWithRetryContext(context.Background(), photoId, photoPath, attempts, sleep)
+}
+
+func (request *Request) ReplaceWithRetryContext(ctx context.Context, photoId string, photoPath string, attempts int, sleep time.Duration) (*ReplaceResult, error) {
+	return request.ReplaceWithPolicyContext(ctx, photoId, photoPath, &RetryPolicy{MaxAttempts: attempts, BaseDelay: sleep})
+}
+
+func (request *Request) ReplaceWithPolicy(photoId string, photoPath string, policy *RetryPolicy) (*ReplaceResult, error) {
+	return request.ReplaceWithPolicyContext(context.Background(), photoId, photoPath, policy)
+}
+
+func (request *Request) ReplaceWithPolicyContext(ctx context.Context, photoId string, photoPath string, policy *RetryPolicy) (*ReplaceResult, error) {
+	var result *ReplaceResult
+	retryErr := request.retry(ctx, policy, func() error {
+		var err error
+		result, err = request.ReplaceContext(ctx, photoId, photoPath)
+		return err
+	})
+	return result, retryErr
+}
+
+func retryPolicy(policy *RetryPolicy) *RetryPolicy {
+	if policy != nil {
+		return policy
+	}
+	return DefaultRetryPolicy
+}
@@ -0,0 +1,136 @@
+package flickr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy decides whether a failed request is tried again and how long
+// to wait before that.
+type RetryPolicy struct {
+	// MaxAttempts counts the first try too; below 2 means no retry.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry. It doubles for every
+	// further retry, up to MaxDelay unless that is 0.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter is the fraction of each wait, from 0 to 1, that is randomly cut
+	// off so clients failing together do not retry together.
+	Jitter float64
+	// Retryable tells errors worth another try from permanent ones; nil
+	// means IsRetryable.
+	Retryable func(err error) bool
+	// OnRetry, if set, is called before waiting for retry number attempt.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// DefaultRetryPolicy tries three times, waiting about 1s and 2s in between.
+// Copy it to change a setting.
+var DefaultRetryPolicy = &RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Second,
+	MaxDelay:    30 * time.Second,
+	Jitter:      0.5,
+}
+
+// Do calls fn until it succeeds, fails permanently or the attempts run out.
+// It gives up early with ctx.Err() once ctx is done.
+func (p *RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= p.MaxAttempts || !p.retryable(err) {
+			return err
+		}
+		delay := p.Delay(attempt, err)
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Delay is the wait after the given failed attempt: what the server asked
+// for with Retry-After, or else the jittered exponential backoff.
+func (p *RetryPolicy) Delay(attempt int, err error) time.Duration {
+	var flickrErr *FlickrError
+	if errors.As(err, &flickrErr) && flickrErr.RetryAfter > 0 {
+		return flickrErr.RetryAfter
+	}
+	delay := p.BaseDelay
+	// Without MaxDelay, stop doubling before the delay overflows
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay) && delay <= math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(p.Jitter * rand.Float64() * float64(delay))
+	}
+	return delay
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// IsRetryable reports whether err may go away on its own: failures to dial,
+// read or write, timeouts, connections cut short, HTTP 429 and 5xx answers
+// and Flickr's "service currently unavailable". Other API errors, like an
+// invalid signature or missing permission, and local errors, like a
+// malformed URL, are permanent.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrRateLimited) {
+		return false
+	}
+	var flickrErr *FlickrError
+	if errors.As(err, &flickrErr) {
+		return flickrErr.Code == 105 || flickrErr.HTTPStatus == http.StatusTooManyRequests || flickrErr.HTTPStatus >= 500
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as a date.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
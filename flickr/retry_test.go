@@ -0,0 +1,147 @@
+package flickr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&FlickrError{Code: 105}, true},
+		{&FlickrError{Code: 96, HTTPStatus: 200}, false},
+		{&FlickrError{Code: 99, Message: "Insufficient permissions"}, false},
+		{&FlickrError{HTTPStatus: http.StatusTooManyRequests}, true},
+		{&FlickrError{HTTPStatus: http.StatusBadGateway}, true},
+		{&FlickrError{HTTPStatus: http.StatusNotFound}, false},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{&url.Error{Op: "Post", URL: "https://up.flickr.com", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, true},
+		{&url.Error{Op: "Get", URL: "https://api.flickr.com", Err: timeoutError{}}, true},
+		{&url.Error{Op: "Get", URL: "ftp://api.flickr.com", Err: errors.New(`unsupported protocol scheme "ftp"`)}, false},
+		{&url.Error{Op: "parse", URL: "http://[::1", Err: errors.New("missing ']' in host")}, false},
+		{context.Canceled, false},
+		{ErrRateLimited, false},
+		{ErrNotImage, false},
+	}
+	for _, test := range tests {
+		if got := IsRetryable(test.err); got != test.want {
+			t.Errorf("%v: got %v", test.err, got)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := &RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := policy.Delay(attempt+1, nil); got != want {
+			t.Errorf("attempt %d: got %s, want %s", attempt+1, got, want)
+		}
+	}
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.Delay(2, nil); got < time.Second || got > 2*time.Second {
+			t.Fatalf("jittered delay %s out of range", got)
+		}
+	}
+	if got := policy.Delay(1, &FlickrError{RetryAfter: time.Minute}); got != time.Minute {
+		t.Errorf("Retry-After ignored: %s", got)
+	}
+
+	// No MaxDelay, as with ExecuteWithRetry
+	uncapped := &RetryPolicy{BaseDelay: time.Second}
+	previous := time.Duration(0)
+	for attempt := 1; attempt <= 200; attempt++ {
+		got := uncapped.Delay(attempt, nil)
+		if got < previous {
+			t.Fatalf("attempt %d: delay %s dropped below %s", attempt, got, previous)
+		}
+		previous = got
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryPolicy(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Query().Get("photo_id") {
+		case "busy":
+			if calls == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			if calls == 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprint(w, `<html><body>Down for maintenance</body></html>`)
+				return
+			}
+			fmt.Fprint(w, `<rsp stat="ok"><sizes /></rsp>`)
+		default:
+			fmt.Fprint(w, `<rsp stat="fail"><err code="96" msg="Invalid signature" /></rsp>`)
+		}
+	})
+	var retries []int
+	client.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Millisecond,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			retries = append(retries, attempt)
+		},
+	}
+	ctx := context.Background()
+
+	if _, err := client.Photos().GetSizes(ctx, "busy"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if calls != 3 || len(retries) != 2 {
+		t.Errorf("%d calls and retries %v", calls, retries)
+	}
+
+	calls, retries = 0, nil
+	if _, err := client.Photos().GetSizes(ctx, "1"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+	if calls != 1 || len(retries) != 0 {
+		t.Errorf("permanent error retried: %d calls", calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	for header, want := range map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"Tue, 01 Jan 2019 12:00:30 GMT": 30 * time.Second,
+		"Tue, 01 Jan 2019 11:00:00 GMT": 0,
+		"soon":                          0,
+	} {
+		if got := parseRetryAfter(header, now); got != want {
+			t.Errorf("%q: got %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestTemplateClientRetryPolicy(t *testing.T) {
+	client := (&RequestTemplate{}).NewClient()
+	client.RetryPolicy.MaxAttempts = 9
+	if DefaultRetryPolicy.MaxAttempts != 3 {
+		t.Errorf("changing a client's policy changed DefaultRetryPolicy to %+v", DefaultRetryPolicy)
+	}
+}
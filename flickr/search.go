@@ -0,0 +1,220 @@
+package flickr
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TagModeAny = "any"
+	TagModeAll = "all"
+
+	MediaAll    = "all"
+	MediaPhotos = "photos"
+	MediaVideos = "videos"
+
+	SortDatePostedAsc       = "date-posted-asc"
+	SortDatePostedDesc      = "date-posted-desc"
+	SortDateTakenAsc        = "date-taken-asc"
+	SortDateTakenDesc       = "date-taken-desc"
+	SortInterestingnessAsc  = "interestingness-asc"
+	SortInterestingnessDesc = "interestingness-desc"
+	SortRelevance           = "relevance"
+
+	// Flickr caps the radius of a point search at 32km, or 20mi.
+	maxRadiusKm = 32
+	maxRadiusMi = 20
+)
+
+// SearchQuery describes a flickr.photos.search call. Zero values are left
+// out of the query; Validate reports combinations Flickr would reject.
+type SearchQuery struct {
+	// UserId limits the search to one user, "me" for the calling user.
+	UserId  string
+	Text    string
+	Tags    []string
+	TagMode string
+
+	MinTakenDate  time.Time
+	MaxTakenDate  time.Time
+	MinUploadDate time.Time
+	MaxUploadDate time.Time
+
+	// BBox is min_longitude, min_latitude, max_longitude, max_latitude.
+	// It cannot be combined with a point search.
+	BBox *[4]float64
+	// Lat, Lon and Radius describe a point search; RadiusUnits is "km"
+	// (the default) or "mi".
+	Lat         *float64
+	Lon         *float64
+	Radius      float64
+	RadiusUnits string
+
+	Media string
+	// PrivacyFilter is 1 for public, 2 friends, 3 family, 4 friends and
+	// family and 5 private photos. It needs an authenticated call.
+	PrivacyFilter int
+	ContentType   int
+	Sort          string
+	Extras        Extras
+}
+
+func (q *SearchQuery) Validate() error {
+	if q.UserId == "" && q.Text == "" && len(q.Tags) == 0 && q.BBox == nil && q.Lat == nil &&
+		q.MinTakenDate.IsZero() && q.MaxTakenDate.IsZero() && q.MinUploadDate.IsZero() && q.MaxUploadDate.IsZero() {
+		return errors.New("Search needs at least one of user, text, tags, dates or a location")
+	}
+	if q.TagMode != "" && q.TagMode != TagModeAny && q.TagMode != TagModeAll {
+		return errors.New("Tag mode must be \"any\" or \"all\", not " + q.TagMode)
+	}
+	if q.TagMode != "" && len(q.Tags) == 0 {
+		return errors.New("Tag mode given without tags")
+	}
+	if !q.MinTakenDate.IsZero() && !q.MaxTakenDate.IsZero() && q.MinTakenDate.After(q.MaxTakenDate) {
+		return errors.New("Min taken date is after max taken date")
+	}
+	if !q.MinUploadDate.IsZero() && !q.MaxUploadDate.IsZero() && q.MinUploadDate.After(q.MaxUploadDate) {
+		return errors.New("Min upload date is after max upload date")
+	}
+	if q.BBox != nil {
+		if q.Lat != nil || q.Lon != nil {
+			return errors.New("A bounding box cannot be combined with lat/lon")
+		}
+		b := q.BBox
+		if !validLon(b[0]) || !validLat(b[1]) || !validLon(b[2]) || !validLat(b[3]) || b[0] >= b[2] || b[1] >= b[3] {
+			return errors.New("Invalid bounding box")
+		}
+	}
+	if (q.Lat == nil) != (q.Lon == nil) {
+		return errors.New("Lat and lon must be given together")
+	}
+	if q.Lat != nil && (!validLat(*q.Lat) || !validLon(*q.Lon)) {
+		return errors.New("Lat or lon out of range")
+	}
+	if q.Radius != 0 {
+		if q.Lat == nil {
+			return errors.New("Radius needs lat and lon")
+		}
+		switch q.RadiusUnits {
+		case "", "km":
+			if q.Radius < 0 || q.Radius > maxRadiusKm {
+				return errors.New("Radius must be between 0 and 32km")
+			}
+		case "mi":
+			if q.Radius < 0 || q.Radius > maxRadiusMi {
+				return errors.New("Radius must be between 0 and 20mi")
+			}
+		default:
+			return errors.New("Radius units must be \"km\" or \"mi\", not " + q.RadiusUnits)
+		}
+	}
+	if q.Media != "" && q.Media != MediaAll && q.Media != MediaPhotos && q.Media != MediaVideos {
+		return errors.New("Media must be \"all\", \"photos\" or \"videos\", not " + q.Media)
+	}
+	if q.PrivacyFilter < 0 || q.PrivacyFilter > 5 {
+		return errors.New("Privacy filter must be between 1 and 5")
+	}
+	if q.ContentType < 0 || q.ContentType > 7 {
+		return errors.New("Content type must be between 1 and 7")
+	}
+	switch q.Sort {
+	case "", SortDatePostedAsc, SortDatePostedDesc, SortDateTakenAsc, SortDateTakenDesc,
+		SortInterestingnessAsc, SortInterestingnessDesc, SortRelevance:
+	default:
+		return errors.New("Unknown sort order " + q.Sort)
+	}
+	return nil
+}
+
+// Args validates the query and returns it as flickr.photos.search arguments.
+func (q *SearchQuery) Args() (map[string]string, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	args := make(map[string]string)
+	set := func(k string, v string) {
+		if v != "" {
+			args[k] = v
+		}
+	}
+	float := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	set("user_id", q.UserId)
+	set("text", q.Text)
+	if len(q.Tags) > 0 {
+		args["tags"] = strings.Join(q.Tags, ",")
+		set("tag_mode", q.TagMode)
+	}
+	if !q.MinTakenDate.IsZero() {
+		args["min_taken_date"] = q.MinTakenDate.Format(takenDateLayout)
+	}
+	if !q.MaxTakenDate.IsZero() {
+		args["max_taken_date"] = q.MaxTakenDate.Format(takenDateLayout)
+	}
+	if !q.MinUploadDate.IsZero() {
+		args["min_upload_date"] = strconv.FormatInt(q.MinUploadDate.Unix(), 10)
+	}
+	if !q.MaxUploadDate.IsZero() {
+		args["max_upload_date"] = strconv.FormatInt(q.MaxUploadDate.Unix(), 10)
+	}
+	if q.BBox != nil {
+		args["bbox"] = float(q.BBox[0]) + "," + float(q.BBox[1]) + "," + float(q.BBox[2]) + "," + float(q.BBox[3])
+	}
+	if q.Lat != nil {
+		args["lat"] = float(*q.Lat)
+		args["lon"] = float(*q.Lon)
+		if q.Radius != 0 {
+			args["radius"] = float(q.Radius)
+			set("radius_units", q.RadiusUnits)
+		}
+	}
+	set("media", q.Media)
+	if q.PrivacyFilter > 0 {
+		args["privacy_filter"] = strconv.Itoa(q.PrivacyFilter)
+	}
+	if q.ContentType > 0 {
+		args["content_type"] = strconv.Itoa(q.ContentType)
+	}
+	set("sort", q.Sort)
+	if len(q.Extras) > 0 {
+		args["extras"] = q.Extras.String()
+	}
+	return args, nil
+}
+
+// Search runs one page of a flickr.photos.search.
+func (s *PhotosService) Search(ctx context.Context, query *SearchQuery, opts *PageOptions) (*Page[Photo], error) {
+	page, perPage := 0, 0
+	if opts != nil {
+		page, perPage = opts.Page, opts.PerPage
+	}
+	return s.searchPages(query)(ctx, page, perPage)
+}
+
+// SearchPaginator walks all results of a flickr.photos.search. An invalid
+// query surfaces through the paginator's Err.
+func (s *PhotosService) SearchPaginator(ctx context.Context, query *SearchQuery, perPage int) *Paginator[Photo] {
+	return NewPaginator(ctx, perPage, s.searchPages(query))
+}
+
+func (s *PhotosService) searchPages(query *SearchQuery) PageFunc[Photo] {
+	args, err := query.Args()
+	if err != nil {
+		return func(context.Context, int, int) (*Page[Photo], error) {
+			return nil, err
+		}
+	}
+	return PageMethod[Photo](s.client, "flickr.photos.search", args, "photos", "photo")
+}
+
+func validLat(lat float64) bool {
+	return lat >= -90 && lat <= 90
+}
+
+func validLon(lon float64) bool {
+	return lon >= -180 && lon <= 180
+}
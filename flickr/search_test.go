@@ -0,0 +1,79 @@
+package flickr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSearchQueryValidate(t *testing.T) {
+	lat, lon := 51.5, -0.12
+	tests := []struct {
+		name  string
+		query SearchQuery
+		valid bool
+	}{
+		{"empty", SearchQuery{}, false},
+		{"text", SearchQuery{Text: "castle"}, true},
+		{"bad tag mode", SearchQuery{Tags: []string{"a"}, TagMode: "some"}, false},
+		{"tag mode without tags", SearchQuery{Text: "a", TagMode: TagModeAll}, false},
+		{"dates reversed", SearchQuery{MinTakenDate: time.Unix(200, 0), MaxTakenDate: time.Unix(100, 0)}, false},
+		{"bbox and point", SearchQuery{BBox: &[4]float64{-1, 50, 1, 52}, Lat: &lat, Lon: &lon}, false},
+		{"inverted bbox", SearchQuery{BBox: &[4]float64{1, 50, -1, 52}}, false},
+		{"lat without lon", SearchQuery{Lat: &lat}, false},
+		{"radius without point", SearchQuery{Text: "a", Radius: 5}, false},
+		{"radius too large", SearchQuery{Lat: &lat, Lon: &lon, Radius: 25, RadiusUnits: "mi"}, false},
+		{"point", SearchQuery{Lat: &lat, Lon: &lon, Radius: 5}, true},
+		{"bad media", SearchQuery{Text: "a", Media: "audio"}, false},
+		{"bad sort", SearchQuery{Text: "a", Sort: "newest"}, false},
+	}
+	for _, test := range tests {
+		if err := test.query.Validate(); (err == nil) != test.valid {
+			t.Errorf("%s: got %v", test.name, err)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		want := map[string]string{
+			"method":         "flickr.photos.search",
+			"tags":           "cat,dog",
+			"tag_mode":       "all",
+			"min_taken_date": "2019-01-01 00:00:00",
+			"bbox":           "-1.5,50,1,52.25",
+			"extras":         "date_taken",
+			"sort":           "date-taken-desc",
+		}
+		for k, v := range want {
+			if r.Form.Get(k) != v {
+				t.Errorf("%s: got %q, want %q", k, r.Form.Get(k), v)
+			}
+		}
+		fmt.Fprintf(w, `<rsp stat="ok"><photos page="%s" pages="2" perpage="1" total="2"><photo id="%s" title="t" datetaken="2019-05-01 00:00:00" /></photos></rsp>`,
+			r.Form.Get("page"), r.Form.Get("page"))
+	})
+	query := &SearchQuery{
+		Tags:         []string{"cat", "dog"},
+		TagMode:      TagModeAll,
+		MinTakenDate: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		BBox:         &[4]float64{-1.5, 50, 1, 52.25},
+		Sort:         SortDateTakenDesc,
+		Extras:       Extras{ExtraDateTaken},
+	}
+	photos, err := client.Photos().SearchPaginator(context.Background(), query, 1).All()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(photos) != 2 || photos[1].Id != "2" || photos[0].DateTaken.Year() != 2019 {
+		t.Errorf("unexpected photos %+v", photos)
+	}
+
+	p := client.Photos().SearchPaginator(context.Background(), &SearchQuery{}, 1)
+	if p.Next() || p.Err() == nil {
+		t.Error("expected the invalid query to fail")
+	}
+}
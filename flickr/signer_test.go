@@ -0,0 +1,120 @@
+package flickr
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func fixedSigner(method string, timestamp int64, nonce string) *Signer {
+	return &Signer{
+		Method: method,
+		Clock:  func() time.Time { return time.Unix(timestamp, 0) },
+		Nonce:  func() string { return nonce },
+	}
+}
+
+// Vectors from the three legs of the example in RFC 5849 section 1.2. The
+// temporary credentials signature is the corrected one from erratum 2550.
+func TestSignerVectors(t *testing.T) {
+	tests := []struct {
+		name       string
+		signer     *Signer
+		httpMethod string
+		url        string
+		args       map[string]string
+		key        string
+		want       string
+	}{
+		{
+			name:       "temporary credentials",
+			signer:     fixedSigner(HMACSHA1, 137131200, "wIjqoS"),
+			httpMethod: http.MethodPost,
+			url:        "https://photos.example.net/initiate",
+			args: map[string]string{
+				"realm":              "Photos",
+				"oauth_consumer_key": "dpf43f3p2l4k3l03",
+				"oauth_callback":     "http://printer.example.com/ready",
+			},
+			key:  SigningKey("kd94hf93k423kf44", ""),
+			want: "74KNZJeDHnMBp0EMJ9ZHt/XKycU=",
+		},
+		{
+			name:       "token credentials",
+			signer:     fixedSigner(HMACSHA1, 137131201, "walatlh"),
+			httpMethod: http.MethodPost,
+			url:        "https://photos.example.net/token",
+			args: map[string]string{
+				"realm":              "Photos",
+				"oauth_consumer_key": "dpf43f3p2l4k3l03",
+				"oauth_token":        "hh5s93j4hdidpola",
+				"oauth_verifier":     "hfdp7dh39dks9884",
+			},
+			key:  SigningKey("kd94hf93k423kf44", "hdhd0244k9j7ao03"),
+			want: "gKgrFCywp7rO0OXSjdot/IHF7IU=",
+		},
+		{
+			name:       "protected resource",
+			signer:     fixedSigner(HMACSHA1, 137131202, "chapoH"),
+			httpMethod: http.MethodGet,
+			url:        "http://photos.example.net/photos?file=vacation.jpg&size=original",
+			args: map[string]string{
+				"realm":              "Photos",
+				"oauth_consumer_key": "dpf43f3p2l4k3l03",
+				"oauth_token":        "nnch734d00sl2jdk",
+			},
+			key:  SigningKey("kd94hf93k423kf44", "pfkkdhi9sl3r4s00"),
+			want: "MdpQcU8iPSUjWoN/UDMsK2sui9I=",
+		},
+		{
+			name:       "plaintext",
+			signer:     fixedSigner(Plaintext, 137131200, "wIjqoS"),
+			httpMethod: http.MethodPost,
+			url:        "https://photos.example.net/initiate",
+			args:       map[string]string{"oauth_consumer_key": "dpf43f3p2l4k3l03"},
+			key:        SigningKey("kd94hf93k423kf44", ""),
+			want:       "kd94hf93k423kf44&",
+		},
+	}
+	for _, test := range tests {
+		if err := test.signer.Sign(test.httpMethod, test.url, test.args, test.key); err != nil {
+			t.Fatalf("%s: %+v", test.name, err)
+		}
+		if got := test.args["oauth_signature"]; got != test.want {
+			t.Errorf("%s: got signature %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBaseString(t *testing.T) {
+	args := map[string]string{
+		"oauth_consumer_key": "9djdj82h48djs9d2",
+		"oauth_token":        "kkk9d7dh3k39sjv7",
+		"title":              "a b+c~d*",
+		"oauth_signature":    "ignored",
+	}
+	got, err := BaseString("post", "HTTP://Example.COM:80/request?b5=%3D%253D&c%40=", args)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	want := "POST&http%3A%2F%2Fexample.com%2Frequest&" +
+		"b5%3D%253D%25253D%26c%2540%3D%26oauth_consumer_key%3D9djdj82h48djs9d2" +
+		"%26oauth_token%3Dkkk9d7dh3k39sjv7%26title%3Da%2520b%252Bc~d%252A"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSignerNonce(t *testing.T) {
+	args := map[string]string{}
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		if err := DefaultSigner.Sign(http.MethodGet, apiEndpoint, args, "key&"); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if seen[args["oauth_nonce"]] {
+			t.Fatalf("nonce %q repeated", args["oauth_nonce"])
+		}
+		seen[args["oauth_nonce"]] = true
+	}
+}
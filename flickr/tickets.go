@@ -0,0 +1,214 @@
+package flickr
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	TicketPending  = 0
+	TicketComplete = 1
+	TicketFailed   = 2
+
+	defaultTicketBatchSize = 20
+	defaultTicketInterval  = 5 * time.Second
+)
+
+// Ticket is the processing state of an async upload or replace.
+type Ticket struct {
+	Id string
+	// Status is TicketPending, TicketComplete or TicketFailed.
+	Status int
+	// Invalid is set for tickets Flickr does not know.
+	Invalid  bool
+	PhotoId  string
+	Imported time.Time
+}
+
+// Done reports whether the ticket will not change any more.
+func (t *Ticket) Done() bool {
+	return t.Invalid || t.Status != TicketPending
+}
+
+type ticketFields struct {
+	Id       flexString `xml:"id,attr" json:"id"`
+	Complete flexInt    `xml:"complete,attr" json:"complete"`
+	Invalid  flexInt    `xml:"invalid,attr" json:"invalid"`
+	PhotoId  flexString `xml:"photoid,attr" json:"photoid"`
+	Imported flexString `xml:"imported,attr" json:"imported"`
+}
+
+// SetAsync makes a later Upload or Replace return as soon as the file is
+// received. Upload then returns a ticket id instead of the photo id and
+// Replace fills in ReplaceResult.TicketId; track them with a TicketTracker.
+func (request *Request) SetAsync(async bool) {
+	if async {
+		request.args["async"] = "1"
+		return
+	}
+	delete(request.args, "async")
+}
+
+// CheckTickets asks for the state of up to a batch of tickets at once.
+func (s *PhotosService) CheckTickets(ctx context.Context, ticketIds []string) ([]Ticket, error) {
+	args := map[string]string{"tickets": strings.Join(ticketIds, ",")}
+	var uploader struct {
+		Ticket []ticketFields `xml:"ticket" json:"ticket"`
+	}
+	if err := s.client.call(ctx, http.MethodGet, "flickr.photos.upload.checkTickets", args, "uploader", &uploader); err != nil {
+		return nil, err
+	}
+	tickets := make([]Ticket, len(uploader.Ticket))
+	for i, f := range uploader.Ticket {
+		tickets[i] = Ticket{
+			Id:       string(f.Id),
+			Status:   int(f.Complete),
+			Invalid:  f.Invalid != 0,
+			PhotoId:  string(f.PhotoId),
+			Imported: parseUnix(string(f.Imported)),
+		}
+	}
+	return tickets, nil
+}
+
+// TicketTracker polls flickr.photos.upload.checkTickets for a set of tickets
+// until each of them completed, failed or turned out invalid:
+//
+//	tracker := client.NewTicketTracker()
+//	tracker.Add(ticketId)
+//	for ticket := range tracker.Track(ctx) {
+//	}
+//	if err := tracker.Err(); err != nil {
+//	}
+type TicketTracker struct {
+	// Interval is the pause before each poll, BatchSize the number of
+	// tickets checked per call.
+	Interval  time.Duration
+	BatchSize int
+	// OnDone, if set, is called with every ticket once it is done.
+	OnDone func(Ticket)
+
+	client  *Client
+	mu      sync.Mutex
+	pending []string
+	err     error
+}
+
+func (c *Client) NewTicketTracker() *TicketTracker {
+	return &TicketTracker{
+		Interval:  defaultTicketInterval,
+		BatchSize: defaultTicketBatchSize,
+		client:    c,
+	}
+}
+
+// Add starts tracking tickets. It is safe to call while Run is polling.
+func (t *TicketTracker) Add(ticketIds ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(t.pending, ticketIds...)
+}
+
+// Pending returns the number of tickets not done yet.
+func (t *TicketTracker) Pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.pending)
+}
+
+// Run polls until all tickets added so far are done or ctx is done, calling
+// OnDone for each finished ticket.
+func (t *TicketTracker) Run(ctx context.Context) error {
+	return t.run(ctx, t.OnDone)
+}
+
+func (t *TicketTracker) run(ctx context.Context, onDone func(Ticket)) error {
+	for t.Pending() > 0 {
+		timer := time.NewTimer(t.Interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if err := t.poll(ctx, onDone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Track runs the tracker in the background and sends each finished ticket
+// on the returned channel, which is closed when Run returns. Err reports
+// why it stopped early.
+func (t *TicketTracker) Track(ctx context.Context) <-chan Ticket {
+	done := make(chan Ticket)
+	go func() {
+		err := t.run(ctx, func(ticket Ticket) {
+			if t.OnDone != nil {
+				t.OnDone(ticket)
+			}
+			select {
+			case done <- ticket:
+			case <-ctx.Done():
+			}
+		})
+		t.mu.Lock()
+		t.err = err
+		t.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+func (t *TicketTracker) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// poll checks every pending ticket once, in batches.
+func (t *TicketTracker) poll(ctx context.Context, onDone func(Ticket)) error {
+	t.mu.Lock()
+	ids := append([]string(nil), t.pending...)
+	t.mu.Unlock()
+
+	batchSize := t.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultTicketBatchSize
+	}
+	finished := make(map[string]bool)
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		tickets, err := t.client.Photos().CheckTickets(ctx, ids[start:end])
+		if err != nil {
+			return err
+		}
+		for _, ticket := range tickets {
+			if !ticket.Done() || finished[ticket.Id] {
+				continue
+			}
+			finished[ticket.Id] = true
+			if onDone != nil {
+				onDone(ticket)
+			}
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pending := t.pending[:0]
+	for _, id := range t.pending {
+		if !finished[id] {
+			pending = append(pending, id)
+		}
+	}
+	t.pending = pending
+	return nil
+}
@@ -0,0 +1,83 @@
+package flickr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTicketTracker(t *testing.T) {
+	polls := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/upload" {
+			r.ParseMultipartForm(1 << 20)
+			if r.FormValue("async") != "1" {
+				t.Errorf("upload not async: %v", r.MultipartForm.Value)
+			}
+			fmt.Fprint(w, `<rsp stat="ok"><ticketid>1</ticketid></rsp>`)
+			return
+		}
+		r.ParseForm()
+		if r.Form.Get("method") != "flickr.photos.upload.checkTickets" {
+			t.Errorf("unexpected method %s", r.Form.Get("method"))
+			http.Error(w, "unexpected method", http.StatusBadRequest)
+			return
+		}
+		polls++
+		ids := strings.Split(r.Form.Get("tickets"), ",")
+		if len(ids) > 2 {
+			t.Errorf("batch of %d tickets", len(ids))
+		}
+		var b strings.Builder
+		for _, id := range ids {
+			switch {
+			case id == "1" && polls > 2:
+				b.WriteString(`<ticket id="1" complete="1" photoid="101" imported="1530000000" />`)
+			case id == "2":
+				b.WriteString(`<ticket id="2" complete="2" />`)
+			case id == "3":
+				b.WriteString(`<ticket id="3" invalid="1" />`)
+			default:
+				b.WriteString(`<ticket id="` + id + `" complete="0" />`)
+			}
+		}
+		fmt.Fprint(w, `<rsp stat="ok"><uploader>`+b.String()+`</uploader></rsp>`)
+	})
+
+	request := client.NewRequest(http.MethodPost, nil)
+	request.SetAsync(true)
+	ticketId, err := request.Upload(writeTestJpeg(t))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	tracker := client.NewTicketTracker()
+	tracker.Interval, tracker.BatchSize = time.Millisecond, 2
+	tracker.Add(ticketId, "2", "3")
+	done := make(map[string]Ticket)
+	for ticket := range tracker.Track(context.Background()) {
+		done[ticket.Id] = ticket
+	}
+	if err := tracker.Err(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(done) != 3 || tracker.Pending() != 0 {
+		t.Fatalf("unexpected tickets %+v", done)
+	}
+	if done["1"].Status != TicketComplete || done["1"].PhotoId != "101" || done["1"].Imported.Unix() != 1530000000 {
+		t.Errorf("unexpected ticket %+v", done["1"])
+	}
+	if done["2"].Status != TicketFailed || !done["3"].Invalid {
+		t.Errorf("unexpected failed tickets %+v %+v", done["2"], done["3"])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tracker.Add("4")
+	if err := tracker.Run(ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
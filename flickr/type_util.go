@@ -5,6 +5,16 @@ import (
 	"gopkg.in/h2non/filetype.v1/types"
 )
 
+// videoTypes are the video containers Flickr accepts.
+var videoTypes = []types.Type{
+	matchers.TypeMp4,
+	matchers.TypeM4v,
+	matchers.TypeMov,
+	matchers.TypeAvi,
+	matchers.TypeWmv,
+	matchers.TypeMpeg,
+}
+
 func IsImage(t types.Type) bool {
 	for k := range matchers.Image {
 		if k == t {
@@ -13,3 +23,12 @@ func IsImage(t types.Type) bool {
 	}
 	return false
 }
+
+func IsVideo(t types.Type) bool {
+	for _, k := range videoTypes {
+		if k == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package flickr
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	filetype "gopkg.in/h2non/filetype.v1"
+	"gopkg.in/h2non/filetype.v1/matchers"
+	"gopkg.in/h2non/filetype.v1/types"
+)
+
+// UploadOptions are the optional arguments of an upload. Zero values and
+// nil pointers are left out, so Flickr falls back to the account defaults.
+type UploadOptions struct {
+	// Title defaults to the file name on Flickr's side.
+	Title       string
+	Description string
+	Tags        []string
+	IsPublic    *bool
+	IsFriend    *bool
+	IsFamily    *bool
+	SafetyLevel int
+	ContentType int
+	// Hidden hides the photo from public searches.
+	Hidden *bool
+}
+
+func (o *UploadOptions) Validate() error {
+	if o.SafetyLevel < 0 || o.SafetyLevel > SafetyLevelRestricted {
+		return errors.New("Safety level must be between 1 and 3")
+	}
+	if o.ContentType < 0 || o.ContentType > ContentTypeOther {
+		return errors.New("Content type must be between 1 and 3")
+	}
+	return nil
+}
+
+func (o *UploadOptions) addArgs(args map[string]string) {
+	if o.Title != "" {
+		args["title"] = o.Title
+	}
+	if o.Description != "" {
+		args["description"] = o.Description
+	}
+	if len(o.Tags) > 0 {
+		args["tags"] = JoinTags(o.Tags)
+	}
+	for k, v := range map[string]*bool{"is_public": o.IsPublic, "is_friend": o.IsFriend, "is_family": o.IsFamily} {
+		if v != nil {
+			args[k] = boolArg(*v)
+		}
+	}
+	if o.SafetyLevel > 0 {
+		args["safety_level"] = strconv.Itoa(o.SafetyLevel)
+	}
+	if o.ContentType > 0 {
+		args["content_type"] = strconv.Itoa(o.ContentType)
+	}
+	// Unlike photos.setSafetyLevel, upload takes 1 for visible and 2 for
+	// hidden.
+	if o.Hidden != nil {
+		if *o.Hidden {
+			args["hidden"] = "2"
+		} else {
+			args["hidden"] = "1"
+		}
+	}
+}
+
+// SetUploadOptions adds opts to the arguments a later Upload sends.
+func (request *Request) SetUploadOptions(opts *UploadOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	opts.addArgs(request.args)
+	return nil
+}
+
+// sniffLen is how much of a reader is looked at to detect its type.
+const sniffLen = 512
+
+// UploadReader uploads the content of r as a file called name, streaming it
+// without buffering the whole content. An empty contentType is sniffed from
+// the first bytes; size is the length of the content, or -1 when unknown.
+// Unlike Upload it cannot be retried since r is consumed.
+func (request *Request) UploadReader(name string, r io.Reader, contentType string, size int64) (photoId string, err error) {
+	return request.UploadReaderContext(context.Background(), name, r, contentType, size)
+}
+
+func (request *Request) UploadReaderContext(ctx context.Context, name string, r io.Reader, contentType string, size int64) (photoId string, err error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	head = head[:n]
+
+	fileType := mediaType(contentType)
+	if contentType == "" {
+		if fileType, err = filetype.Match(head); err != nil {
+			return "", err
+		}
+		contentType = fileType.MIME.Value
+	}
+	if err := request.getClient().uploadLimits().checkSize(name, fileType, size); err != nil {
+		return "", err
+	}
+
+	body := io.MultiReader(bytes.NewReader(head), r)
+	response, err := request.post(ctx, request.getClient().uploadEndpoint(), name, body, contentType, size, uploadMethod)
+	if err != nil {
+		return "", err
+	}
+	err = xml.Unmarshal([]byte(response.Payload), &photoId)
+	return photoId, err
+}
+
+// mediaType looks up the image or video type of a MIME type.
+func mediaType(contentType string) types.Type {
+	contentType = strings.TrimSpace(strings.Split(contentType, ";")[0])
+	for t := range matchers.Image {
+		if t.MIME.Value == contentType {
+			return t
+		}
+	}
+	for _, t := range videoTypes {
+		if t.MIME.Value == contentType {
+			return t
+		}
+	}
+	return types.Unknown
+}
@@ -0,0 +1,198 @@
+package flickr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestJpeg(t *testing.T) string {
+	photopath := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(photopath, []byte{0xFF, 0xD8, 0xFF, 0xE0, 0, 0x10, 'J', 'F', 'I', 'F'}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return photopath
+}
+
+func TestUploadOptions(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+			return
+		}
+		want := map[string]string{
+			"title":        "Sunset",
+			"description":  "Over the bay",
+			"tags":         `beach "new york"`,
+			"is_public":    "0",
+			"is_family":    "1",
+			"is_friend":    "",
+			"safety_level": "2",
+			"content_type": "1",
+			"hidden":       "2",
+		}
+		for k, v := range want {
+			if got := r.FormValue(k); got != v {
+				t.Errorf("%s: got %q, want %q", k, got, v)
+			}
+		}
+		fmt.Fprint(w, `<rsp stat="ok"><photoid>42</photoid></rsp>`)
+	})
+
+	no, yes := false, true
+	request := client.NewRequest(http.MethodPost, nil)
+	err := request.SetUploadOptions(&UploadOptions{
+		Title:       "Sunset",
+		Description: "Over the bay",
+		Tags:        []string{"beach", "new york"},
+		IsPublic:    &no,
+		IsFamily:    &yes,
+		SafetyLevel: SafetyLevelModerate,
+		ContentType: ContentTypePhoto,
+		Hidden:      &yes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	photoId, err := request.Upload(writeTestJpeg(t))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if photoId != "42" {
+		t.Errorf("unexpected photo id %q", photoId)
+	}
+
+	if err := request.SetUploadOptions(&UploadOptions{SafetyLevel: 4}); err == nil {
+		t.Error("expected an error for safety level 4")
+	}
+}
+
+func TestUploadReader(t *testing.T) {
+	content := append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, bytes.Repeat([]byte("jpeg"), 1000)...)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+			return
+		}
+		file, header, err := r.FormFile("photo")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		if header.Filename != "from-memory.jpg" || header.Header.Get("Content-Type") != "image/jpeg" || !bytes.Equal(data, content) {
+			t.Errorf("unexpected file %s %v, %d bytes", header.Filename, header.Header, len(data))
+		}
+		fmt.Fprintf(w, `<rsp stat="ok"><photoid>%d</photoid></rsp>`, r.ContentLength)
+	})
+
+	for _, size := range []int64{int64(len(content)), -1} {
+		// A reader that hides its length, like a network stream.
+		r := io.MultiReader(bytes.NewReader(content))
+		photoId, err := client.NewRequest(http.MethodPost, nil).UploadReader("from-memory.jpg", r, "", size)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if (size < 0) != (photoId == "-1") {
+			t.Errorf("size %d sent with content length %s", size, photoId)
+		}
+	}
+
+	if _, err := client.NewRequest(http.MethodPost, nil).UploadReader("from-memory.jpg", bytes.NewReader(content), "image/jpeg", -1); err != nil {
+		t.Errorf("explicit content type: %v", err)
+	}
+	if _, err := client.NewRequest(http.MethodPost, nil).UploadReader("notes.txt", strings.NewReader("hello"), "", 5); !errors.Is(err, ErrNotImage) {
+		t.Errorf("expected ErrNotImage, got %v", err)
+	}
+	client.UploadLimits = &UploadLimits{MaxPhotoSize: 100}
+	if _, err := client.NewRequest(http.MethodPost, nil).UploadReader("big.jpg", bytes.NewReader(content), "", int64(len(content))); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+// failingReader returns its content and then err.
+type failingReader struct {
+	content io.Reader
+	err     error
+}
+
+func (f *failingReader) Read(b []byte) (int, error) {
+	n, err := f.content.Read(b)
+	if err == io.EOF {
+		return n, f.err
+	}
+	return n, err
+}
+
+func TestMultipartBody(t *testing.T) {
+	content := append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, bytes.Repeat([]byte{1, 2, 3}, 5000)...)
+	name := "my \"best\" \\ 照片\r\n.jpg"
+	received := make(chan bool, 10)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			// Aborted uploads never reach a complete body
+			received <- false
+			return
+		}
+		received <- true
+		if r.ContentLength >= 0 && r.ContentLength != int64(len(body)) {
+			t.Errorf("content length %d for %d bytes", r.ContentLength, len(body))
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+			return
+		}
+		if r.FormValue("title") != "a \"quoted\" title" || r.FormValue("oauth_signature") == "" {
+			t.Errorf("unexpected fields %v", r.MultipartForm.Value)
+		}
+		file, header, err := r.FormFile("photo")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		if header.Filename != "my \"best\" \\ 照片%0D%0A.jpg" || !bytes.Equal(data, content) {
+			t.Errorf("unexpected file %q, %d bytes", header.Filename, len(data))
+		}
+		fmt.Fprint(w, `<rsp stat="ok"><photoid>1</photoid></rsp>`)
+	})
+	upload := func(r io.Reader, size int64) error {
+		request := client.NewRequest(http.MethodPost, map[string]string{"title": "a \"quoted\" title"})
+		_, err := request.UploadReader(name, r, "image/jpeg", size)
+		return err
+	}
+
+	for _, size := range []int64{int64(len(content)), -1} {
+		if err := upload(bytes.NewReader(content), size); err != nil {
+			t.Fatalf("size %d: %+v", size, err)
+		}
+		if !<-received {
+			t.Errorf("size %d: body not received", size)
+		}
+	}
+
+	readErr := errors.New("disk on fire")
+	if err := upload(&failingReader{bytes.NewReader(content), readErr}, -1); err == nil || !strings.Contains(err.Error(), readErr.Error()) {
+		t.Errorf("expected the read error, got %v", err)
+	}
+	if err := upload(bytes.NewReader(content), int64(len(content))+10); err == nil {
+		t.Error("expected an error for a short read")
+	}
+	select {
+	case ok := <-received:
+		if ok {
+			t.Error("failed upload reached the server complete")
+		}
+	default:
+	}
+}
@@ -0,0 +1,168 @@
+package flickr
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"gopkg.in/h2non/filetype.v1/matchers"
+	"gopkg.in/h2non/filetype.v1/types"
+)
+
+// UploadLimits are checked before a file is sent, so an oversized file
+// fails fast instead of after the whole upload. Zero fields are not checked.
+type UploadLimits struct {
+	MaxPhotoSize     int64
+	MaxVideoSize     int64
+	MaxVideoDuration time.Duration
+}
+
+// DefaultUploadLimits are Flickr's limits for Pro accounts.
+var DefaultUploadLimits = &UploadLimits{
+	MaxPhotoSize:     200 << 20,
+	MaxVideoSize:     1 << 30,
+	MaxVideoDuration: 10 * time.Minute,
+}
+
+// check fails for files Flickr would not take: anything but images and
+// supported videos, and files over the limits. The duration is only known
+// for MP4 and QuickTime videos; other containers pass unchecked.
+func (l *UploadLimits) check(path string, t types.Type) error {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := l.checkSize(path, t, stat.Size()); err != nil {
+		return err
+	}
+	if !IsVideo(t) || l.MaxVideoDuration <= 0 || (t != matchers.TypeMp4 && t != matchers.TypeM4v && t != matchers.TypeMov) {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	duration, err := mp4Duration(f)
+	if err != nil {
+		// Leave broken or unusual files for Flickr to judge
+		return nil
+	}
+	if duration > l.MaxVideoDuration {
+		return fmt.Errorf("%s runs %s, longer than %s: %w", path, duration, l.MaxVideoDuration, ErrTooLong)
+	}
+	return nil
+}
+
+// checkSize is check for content that is not a file; a negative size is
+// not checked.
+func (l *UploadLimits) checkSize(name string, t types.Type, size int64) error {
+	video := IsVideo(t)
+	if !video && !IsImage(t) {
+		return fmt.Errorf("%s: %w", name, ErrNotImage)
+	}
+	maxSize := l.MaxPhotoSize
+	if video {
+		maxSize = l.MaxVideoSize
+	}
+	if maxSize > 0 && size > maxSize {
+		return fmt.Errorf("%s is %d bytes, more than %d: %w", name, size, maxSize, ErrTooLarge)
+	}
+	return nil
+}
+
+// mp4Duration reads the duration from the movie header (mvhd) inside the
+// moov box of an MP4 or QuickTime file.
+func mp4Duration(r io.ReadSeeker) (time.Duration, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	moov, moovEnd, err := findBox(r, 0, end, "moov")
+	if err != nil {
+		return 0, err
+	}
+	mvhd, _, err := findBox(r, moov, moovEnd, "mvhd")
+	if err != nil {
+		return 0, err
+	}
+	if _, err := r.Seek(mvhd, io.SeekStart); err != nil {
+		return 0, err
+	}
+	var version [4]byte
+	if _, err := io.ReadFull(r, version[:]); err != nil {
+		return 0, err
+	}
+	var timescale uint32
+	var duration uint64
+	unknown := uint64(math.MaxUint32)
+	if version[0] == 1 {
+		var header struct {
+			Created, Modified uint64
+			Timescale         uint32
+			Duration          uint64
+		}
+		if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+			return 0, err
+		}
+		timescale, duration, unknown = header.Timescale, header.Duration, math.MaxUint64
+	} else {
+		var header struct {
+			Created, Modified uint32
+			Timescale         uint32
+			Duration          uint32
+		}
+		if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+			return 0, err
+		}
+		timescale, duration = header.Timescale, uint64(header.Duration)
+	}
+	if timescale == 0 {
+		return 0, errors.New("Zero timescale in mvhd")
+	}
+	// All ones marks an unknown duration, as in fragmented MP4
+	if duration == unknown {
+		return 0, errors.New("Unknown duration in mvhd")
+	}
+	seconds, rest := duration/uint64(timescale), duration%uint64(timescale)
+	if seconds > math.MaxInt64/uint64(time.Second) {
+		return 0, errors.New("Duration in mvhd out of range")
+	}
+	return time.Duration(seconds)*time.Second + time.Duration(rest)*time.Second/time.Duration(timescale), nil
+}
+
+// findBox looks for a box of the given type among the boxes between start
+// and end and returns the range of its content.
+func findBox(r io.ReadSeeker, start int64, end int64, boxType string) (int64, int64, error) {
+	for offset := start; offset+8 <= end; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return 0, 0, err
+		}
+		var header [16]byte
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return 0, 0, err
+		}
+		size, headerLen := int64(binary.BigEndian.Uint32(header[:4])), int64(8)
+		switch size {
+		case 0:
+			size = end - offset
+		case 1:
+			if _, err := io.ReadFull(r, header[8:]); err != nil {
+				return 0, 0, err
+			}
+			size, headerLen = int64(binary.BigEndian.Uint64(header[8:])), 16
+		}
+		if size < headerLen || offset+size > end {
+			return 0, 0, errors.New("Malformed " + string(header[4:8]) + " box")
+		}
+		if string(header[4:8]) == boxType {
+			return offset + headerLen, offset + size, nil
+		}
+		offset += size
+	}
+	return 0, 0, errors.New("No " + boxType + " box")
+}
@@ -0,0 +1,131 @@
+package flickr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	filetype "gopkg.in/h2non/filetype.v1"
+)
+
+// box builds an MP4 box; large uses the 64-bit size form.
+func box(boxType string, large bool, content ...[]byte) []byte {
+	body := bytes.Join(content, nil)
+	var b bytes.Buffer
+	if large {
+		binary.Write(&b, binary.BigEndian, uint32(1))
+		b.WriteString(boxType)
+		binary.Write(&b, binary.BigEndian, uint64(16+len(body)))
+	} else {
+		binary.Write(&b, binary.BigEndian, uint32(8+len(body)))
+		b.WriteString(boxType)
+	}
+	b.Write(body)
+	return b.Bytes()
+}
+
+func testMp4(version byte, timescale uint32, duration uint64) []byte {
+	var mvhd bytes.Buffer
+	mvhd.Write([]byte{version, 0, 0, 0})
+	if version == 1 {
+		binary.Write(&mvhd, binary.BigEndian, []uint64{0, 0})
+		binary.Write(&mvhd, binary.BigEndian, timescale)
+		binary.Write(&mvhd, binary.BigEndian, duration)
+	} else {
+		binary.Write(&mvhd, binary.BigEndian, []uint32{0, 0, timescale, uint32(duration)})
+	}
+	mvhd.Write(make([]byte, 80))
+	return bytes.Join([][]byte{
+		box("ftyp", false, []byte("isom\x00\x00\x02\x00isomiso2mp41")),
+		box("free", true, make([]byte, 10)),
+		box("moov", false, box("mvhd", false, mvhd.Bytes()), box("trak", false)),
+		box("mdat", false, make([]byte, 64)),
+	}, nil)
+}
+
+func TestMp4Duration(t *testing.T) {
+	for _, test := range []struct {
+		data []byte
+		want time.Duration
+	}{
+		{testMp4(0, 600, 90*600), 90 * time.Second},
+		{testMp4(1, 90000, 15*60*90000), 15 * time.Minute},
+	} {
+		got, err := mp4Duration(bytes.NewReader(test.data))
+		if err != nil || got != test.want {
+			t.Errorf("got %s, %v, want %s", got, err, test.want)
+		}
+	}
+	if _, err := mp4Duration(bytes.NewReader([]byte("not a video at all"))); err == nil {
+		t.Error("expected an error without moov")
+	}
+	for _, data := range [][]byte{
+		testMp4(1, 90000, math.MaxUint64),
+		testMp4(0, 600, math.MaxUint32),
+		testMp4(1, 1, 1<<62),
+	} {
+		if got, err := mp4Duration(bytes.NewReader(data)); err == nil {
+			t.Errorf("expected an error for an unknown or huge duration, got %s", got)
+		}
+	}
+}
+
+func TestUploadLimits(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	check := func(limits *UploadLimits, path string) error {
+		fileType, err := filetype.MatchFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return limits.check(path, fileType)
+	}
+
+	long := write("long.mp4", testMp4(0, 600, 20*60*600))
+	short := write("short.mp4", testMp4(0, 600, 60*600))
+	text := write("notes.txt", []byte("hello"))
+	if err := check(DefaultUploadLimits, short); err != nil {
+		t.Errorf("short video: %v", err)
+	}
+	if err := check(DefaultUploadLimits, long); !errors.Is(err, ErrTooLong) {
+		t.Errorf("long video: %v", err)
+	}
+	if err := check(&UploadLimits{}, long); err != nil {
+		t.Errorf("no limits: %v", err)
+	}
+	if err := check(&UploadLimits{MaxVideoSize: 100}, short); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("large video: %v", err)
+	}
+	if err := check(&UploadLimits{MaxPhotoSize: 5}, writeTestJpeg(t)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("large photo: %v", err)
+	}
+	if err := check(DefaultUploadLimits, text); !errors.Is(err, ErrNotImage) {
+		t.Errorf("text file: %v", err)
+	}
+}
+
+func TestSizesVideo(t *testing.T) {
+	sizes := Sizes{Size: []Size{
+		{Label: "Original", Source: "http://example.com/o.jpg", Media: "photo"},
+		{Label: "Site MP4", Source: "http://example.com/site.mp4", Media: "video"},
+		{Label: "Video Player", Source: "http://example.com/player", Media: "video"},
+	}}
+	if video := sizes.Video(); video == nil || video.Label != "Site MP4" {
+		t.Errorf("unexpected video size %+v", video)
+	}
+	sizes.Size = append(sizes.Size, Size{Label: "Video Original", Source: "http://example.com/orig", Media: "video"})
+	if video := sizes.Video(); video == nil || video.Label != "Video Original" {
+		t.Errorf("unexpected video size %+v", video)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/wgu/go-flickr/flickr"
+)
+
+func main() {
+	var photoId, photoPath string
+	var async bool
+	flag.StringVar(&photoId, "photo_id", "", "Required. Id of the photo to replace.")
+	flag.StringVar(&photoPath, "file", "", "Required. Path of the new image file.")
+	flag.BoolVar(&async, "async", false, "Optional. Return a ticket instead of waiting for Flickr to process the file.")
+	requestTemplate, err := flickr.NewRequestFromCmd()
+	flickr.CheckErr(err)
+	slog.SetDefault(requestTemplate.Logger)
+	if photoId == "" || photoPath == "" {
+		flickr.CheckErr(errors.New("Missing `photo_id` or `file`"))
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client := requestTemplate.NewClient()
+	request := client.NewRequest(http.MethodPost, nil)
+	request.SetAsync(async)
+	result, err := request.ReplaceWithPolicyContext(ctx, photoId, photoPath, client.RetryPolicy)
+	flickr.CheckErr(err)
+	if result.TicketId != "" {
+		fmt.Println("Ticket: " + result.TicketId)
+		return
+	}
+	fmt.Println("Replaced " + result.PhotoId)
+	fmt.Println("Secret: " + result.Secret)
+	fmt.Println("Original secret: " + result.OriginalSecret)
+}
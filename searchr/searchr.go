@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wgu/go-flickr/flickr"
+)
+
+func main() {
+	var query flickr.SearchQuery
+	var tags, minTaken, maxTaken, minUpload, maxUpload, bbox, lat, lon, extras string
+	var limit int
+	flag.StringVar(&query.UserId, "user_id", "", "Optional. Only photos of this user, \"me\" for yourself.")
+	flag.StringVar(&query.Text, "text", "", "Optional. Free text to search titles, descriptions and tags for.")
+	flag.StringVar(&tags, "tags", "", "Optional. Comma separated tags.")
+	flag.StringVar(&query.TagMode, "tag_mode", "", "Optional. \"any\" or \"all\" of the tags.")
+	flag.StringVar(&minTaken, "min_taken_date", "", "Optional. Format: 2006-01-02.")
+	flag.StringVar(&maxTaken, "max_taken_date", "", "Optional. Format: 2006-01-02.")
+	flag.StringVar(&minUpload, "min_upload_date", "", "Optional. Format: 2006-01-02.")
+	flag.StringVar(&maxUpload, "max_upload_date", "", "Optional. Format: 2006-01-02.")
+	flag.StringVar(&bbox, "bbox", "", "Optional. Format: \"min_lon,min_lat,max_lon,max_lat\".")
+	flag.StringVar(&lat, "lat", "", "Optional. Latitude of a point search.")
+	flag.StringVar(&lon, "lon", "", "Optional. Longitude of a point search.")
+	flag.Float64Var(&query.Radius, "radius", 0, "Optional. Radius of a point search.")
+	flag.StringVar(&query.RadiusUnits, "radius_units", "", "Optional. \"km\" or \"mi\".")
+	flag.StringVar(&query.Media, "media", "", "Optional. \"all\", \"photos\" or \"videos\".")
+	flag.IntVar(&query.PrivacyFilter, "privacy_filter", 0, "Optional. 1 public, 2 friends, 3 family, 4 friends & family, 5 private.")
+	flag.IntVar(&query.ContentType, "content_type", 0, "Optional. Content type 1 to 7.")
+	flag.StringVar(&query.Sort, "sort", "", "Optional. E.g. date-taken-desc or relevance.")
+	flag.StringVar(&extras, "extras", "date_taken,url_o", "Optional. Comma separated extras.")
+	flag.IntVar(&limit, "limit", 100, "Maximum number of results to print, 0 for all.")
+	requestTemplate, err := flickr.NewRequestFromCmd()
+	flickr.CheckErr(err)
+	slog.SetDefault(requestTemplate.Logger)
+	client := requestTemplate.NewClient()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if tags != "" {
+		query.Tags = strings.Split(tags, ",")
+	}
+	if extras != "" {
+		query.Extras = strings.Split(extras, ",")
+	}
+	query.MinTakenDate = parseDate(minTaken)
+	query.MaxTakenDate = parseDate(maxTaken)
+	query.MinUploadDate = parseDate(minUpload)
+	query.MaxUploadDate = parseDate(maxUpload)
+	if bbox != "" {
+		parts := strings.Split(bbox, ",")
+		if len(parts) != 4 {
+			flickr.CheckErr(errors.New("Wrong format of `bbox` " + bbox))
+		}
+		var b [4]float64
+		for i, p := range parts {
+			b[i] = parseFloat(p)
+		}
+		query.BBox = &b
+	}
+	if lat != "" {
+		v := parseFloat(lat)
+		query.Lat = &v
+	}
+	if lon != "" {
+		v := parseFloat(lon)
+		query.Lon = &v
+	}
+	flickr.CheckErr(query.Validate())
+
+	results := client.Photos().SearchPaginator(ctx, &query, 0)
+	count := 0
+	for (limit == 0 || count < limit) && results.Next() {
+		photo := results.Item()
+		if count == 0 {
+			fmt.Printf("%d photos found\n", results.Total())
+		}
+		count++
+		line := photo.Id + "\t" + photo.Title
+		if !photo.DateTaken.IsZero() {
+			line += "\t" + photo.DateTaken.Format("2006-01-02 15:04:05")
+		}
+		if largest := photo.Largest(); largest != nil {
+			line += "\t" + largest.Source
+		}
+		fmt.Println(line)
+	}
+	flickr.CheckErr(results.Err())
+	if count == 0 {
+		fmt.Println("No photos found")
+	}
+}
+
+func parseDate(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse("2006-01-02", s)
+	flickr.CheckErr(err)
+	return t
+}
+
+func parseFloat(s string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	flickr.CheckErr(err)
+	return f
+}
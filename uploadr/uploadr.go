@@ -1,155 +1,305 @@
 package main
 
 import (
-	"encoding/xml"
+	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/wgu/go-flickr/flickr"
 )
 
+// dirDefaultsFile holds per-directory defaults for the upload option flags,
+// one "name=value" per line. Flags given on the command line win.
+const dirDefaultsFile = ".uploadr"
+
+// optionalBool is a bool flag that remembers whether it was given at all, so
+// unset privacy flags fall back to the account defaults.
+type optionalBool struct {
+	value *bool
+}
+
+func (b *optionalBool) String() string {
+	if b == nil || b.value == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b.value)
+}
+
+func (b *optionalBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	b.value = &v
+	return nil
+}
+
+func (b *optionalBool) IsBoolFlag() bool {
+	return true
+}
+
+// tagList is a comma separated list flag. Blanks around the tags and empty
+// tags are dropped.
+type tagList []string
+
+func (l *tagList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *tagList) Set(s string) error {
+	*l = nil
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			*l = append(*l, tag)
+		}
+	}
+	return nil
+}
+
+var uploadOptionFlags = []string{"title", "description", "tags", "is_public", "is_friend", "is_family", "safety_level", "content_type", "hidden"}
+
 func main() {
+	var title, description string
+	var tags tagList
+	var isPublic, isFriend, isFamily, hidden optionalBool
+	var async bool
+	var options flickr.UploadOptions
+	flag.StringVar(&title, "title", "", "Optional. Title of each photo, \"{name}\" is replaced by the file name without extension. Defaults to the file name.")
+	flag.StringVar(&description, "description", "", "Optional. Description of each photo.")
+	flag.Var(&tags, "tags", "Optional. Comma separated tags for each photo.")
+	flag.Var(&isPublic, "is_public", "Optional. Whether the photos are public. Defaults to the account setting.")
+	flag.Var(&isFriend, "is_friend", "Optional. Whether friends may see the photos. Defaults to the account setting.")
+	flag.Var(&isFamily, "is_family", "Optional. Whether family may see the photos. Defaults to the account setting.")
+	flag.IntVar(&options.SafetyLevel, "safety_level", 0, "Optional. 1 safe, 2 moderate, 3 restricted.")
+	flag.IntVar(&options.ContentType, "content_type", 0, "Optional. 1 photo, 2 screenshot, 3 other.")
+	flag.Var(&hidden, "hidden", "Optional. Whether the photos are hidden from public searches.")
+	flag.BoolVar(&async, "async", false, "Optional. Do not wait for Flickr to process each file, poll for all of them at the end instead. Use it for big files that time out.")
 	requestTemplate, err := flickr.NewRequestFromCmd()
 	flickr.CheckErr(err)
+	slog.SetDefault(requestTemplate.Logger)
+	flickr.CheckErr(loadDirDefaults(requestTemplate.Dir))
+	options.Description = description
+	options.Tags = tags
+	options.IsPublic, options.IsFriend, options.IsFamily, options.Hidden = isPublic.value, isFriend.value, isFamily.value, hidden.value
+	flickr.CheckErr(options.Validate())
+	client := requestTemplate.NewClient()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
 	var photosetid string
-	uploadedPhotoSet := flickr.Photoset{}
+	uploaded := make(map[string]bool)
 
 	// Specified album name to upload photos to or be created
 	if requestTemplate.Album != "" {
-		args := map[string]string{
-			"method": "flickr.photosets.getList",
-		}
-		request := flickr.NewRequest(http.MethodGet, requestTemplate.Auth, args, requestTemplate.Secret)
-		response, err := request.ExecuteWithRetry(2, time.Second)
-		flickr.CheckErr(err, response)
-		var photoSets flickr.Photosets
-		flickr.CheckErr(xml.Unmarshal([]byte(response), &photoSets), response)
-		for _, photoSet := range photoSets.Photoset {
-			if photoSet.Title != requestTemplate.Album {
+		photoSets := client.Photosets().GetListPaginator(ctx, "", 0)
+		for photoSets.Next() {
+			if photoSets.Item().Title != requestTemplate.Album {
 				continue
 			}
-			photosetid = photoSet.Id
-			args = map[string]string{
-				"method":      "flickr.photosets.getPhotos",
-				"photoset_id": photosetid,
+			photosetid = photoSets.Item().Id
+			photos := client.Photosets().GetPhotosPaginator(ctx, photosetid, nil, 500)
+			for photos.Next() {
+				uploaded[photos.Item().Title] = true
 			}
-			request = flickr.NewRequest(http.MethodGet, requestTemplate.Auth, args, requestTemplate.Secret)
-			response, err = request.ExecuteWithRetry(2, time.Second)
-			flickr.CheckErr(err, response)
-			flickr.CheckErr(xml.Unmarshal([]byte(response), &uploadedPhotoSet), response)
+			flickr.CheckErr(photos.Err())
 			break
 		}
+		flickr.CheckErr(photoSets.Err())
 	}
 
+	addToAlbum := func(photoid string) {
+		// No album yet
+		if photosetid == "" {
+			fmt.Println("Creating album")
+			albumTitle := requestTemplate.Album
+			if albumTitle == "" {
+				albumTitle = filepath.Base(requestTemplate.Dir)
+			}
+			pset, err := client.Photosets().Create(ctx, albumTitle, "", photoid)
+			flickr.CheckErr(err)
+			photosetid = pset.Id
+			fmt.Println("Photaset id: " + photosetid)
+		} else {
+			fmt.Println("Adding " + photoid + " to album")
+			flickr.CheckErr(client.Photosets().AddPhoto(ctx, photosetid, photoid))
+		}
+	}
+
+	tracker := client.NewTicketTracker()
+	ticketFiles := make(map[string]string)
 	files, err := ioutil.ReadDir(requestTemplate.Dir)
 	flickr.CheckErr(err)
+	// Bytes of all files and of the files done, for the overall progress
+	var totalBytes, doneBytes int64
+	for _, fileinfo := range files {
+		if !fileinfo.IsDir() && fileinfo.Name() != dirDefaultsFile {
+			totalBytes += fileinfo.Size()
+		}
+	}
 	for _, fileinfo := range files {
 		filename := fileinfo.Name()
+		if fileinfo.IsDir() || filename == dirDefaultsFile {
+			continue
+		}
+		doneBytes += fileinfo.Size()
 		filenameExt := filepath.Ext(filename)
 		filenameBase := filename[:len(filename)-len(filenameExt)]
+		options.Title = filenameBase
+		if title != "" {
+			options.Title = strings.Replace(title, "{name}", filenameBase, -1)
+		}
 
 		// Album already exists
-		if photosetid != "" {
-			var uploaded bool
-			for _, p := range uploadedPhotoSet.Photo {
-				if filenameBase == p.Title {
-					uploaded = true
-				}
-			}
-			if uploaded {
-				fmt.Println("Already exists: " + filename)
-				continue
-			}
+		if uploaded[options.Title] {
+			fmt.Println("Already exists: " + filename)
+			continue
 		}
 
 		fmt.Println("Uploading " + filename)
 		photopath := filepath.Join(requestTemplate.Dir, filename)
-		request := flickr.NewRequest(http.MethodPost, requestTemplate.Auth, nil, requestTemplate.Secret)
-		photoid, err := request.UploadWithRetry(photopath, 2, time.Second)
-		if err != nil && err.Error() == photopath+" is not an image." {
+		request := client.NewRequest(http.MethodPost, nil)
+		flickr.CheckErr(request.SetUploadOptions(&options))
+		request.SetAsync(async)
+		before := doneBytes - fileinfo.Size()
+		request.SetProgress(func(p flickr.Progress) {
+			printProgress(p, before+p.Sent, totalBytes)
+		})
+		photoid, err := request.UploadWithPolicyContext(ctx, photopath, client.RetryPolicy)
+		if errors.Is(err, flickr.ErrNotImage) || errors.Is(err, flickr.ErrTooLarge) || errors.Is(err, flickr.ErrTooLong) {
 			fmt.Println(err.Error() + " Skipped...")
 			continue
 		}
 		flickr.CheckErr(err)
 
-		// No album yet
-		if photosetid == "" {
-			fmt.Println("Creating album")
-			title := requestTemplate.Album
-			if title == "" {
-				title = filepath.Base(requestTemplate.Dir)
-			}
-			additionalArgs := map[string]string{
-				"method":           "flickr.photosets.create",
-				"title":            title,
-				"primary_photo_id": photoid,
-			}
-			request = flickr.NewRequest(http.MethodPost, requestTemplate.Auth, additionalArgs, requestTemplate.Secret)
-			response, err := request.ExecuteWithRetry(2, time.Second)
-			flickr.CheckErr(err, response)
-			// fmt.Println(response)
-			var pset flickr.Photoset
-			flickr.CheckErr(xml.Unmarshal([]byte(response), &pset), response)
-			photosetid = pset.Id
-			fmt.Println("Photaset id: " + photosetid)
-		} else {
-			fmt.Println("Adding " + photoid + " to album")
-			additionalArgs := map[string]string{
-				"method":      "flickr.photosets.addPhoto",
-				"photoset_id": photosetid,
-				"photo_id":    photoid,
+		if async {
+			// photoid is a ticket id until Flickr has processed the file
+			tracker.Add(photoid)
+			ticketFiles[photoid] = filename
+			continue
+		}
+		addToAlbum(photoid)
+	}
+
+	if tracker.Pending() > 0 {
+		fmt.Printf("Waiting for Flickr to process %d files\n", tracker.Pending())
+		for ticket := range tracker.Track(ctx) {
+			filename := ticketFiles[ticket.Id]
+			switch {
+			case ticket.Invalid:
+				fmt.Println("Unknown ticket for " + filename)
+			case ticket.Status == flickr.TicketFailed:
+				fmt.Println("Processing failed: " + filename)
+			default:
+				fmt.Println("Processed " + filename)
+				addToAlbum(ticket.PhotoId)
 			}
-			request = flickr.NewRequest(http.MethodPost, requestTemplate.Auth, additionalArgs, requestTemplate.Secret)
-			response, err := request.ExecuteWithRetry(2, time.Second)
-			flickr.CheckErr(err, response)
 		}
+		flickr.CheckErr(tracker.Err())
 	}
 
 	if photosetid != "" && requestTemplate.Collection != "" {
-		additionalArgs := map[string]string{
-			"method": "flickr.collections.getTree",
+		fmt.Println("Looking up collection " + requestTemplate.Collection)
+		collection, err := client.Collections().CreatePath(ctx, requestTemplate.Collection)
+		flickr.CheckErr(err)
+		fmt.Println("Adding album " + photosetid + " to collection")
+		err = client.Collections().AddSet(ctx, collection.Id, photosetid)
+		if errors.Is(err, flickr.ErrAlreadyInSet) {
+			fmt.Println("Album already in collection")
+		} else {
+			flickr.CheckErr(err)
 		}
-		request := flickr.NewRequest(http.MethodGet, requestTemplate.Auth, additionalArgs, requestTemplate.Secret)
-		response, err := request.ExecuteWithRetry(2, time.Second)
-		flickr.CheckErr(err, response)
-		var cs flickr.Collections
-		flickr.CheckErr(xml.Unmarshal([]byte(response), &cs), response)
-		var collectionId string
-		for _, c := range cs.Collection {
-			if c.Title == requestTemplate.Collection {
-				collectionId = c.Id
-				break
-			}
+	}
+	if client.RateLimiter != nil {
+		usage := client.RateLimiter.Usage()
+		fmt.Printf("Used %d API calls, %.0f left in the current burst\n", usage.Calls.Used, usage.Calls.Available)
+	}
+}
+
+// printProgress keeps one status line per file up to date, ending it once
+// the file is sent.
+func printProgress(p flickr.Progress, overallSent int64, overallTotal int64) {
+	line := "  " + formatBytes(p.Sent)
+	if p.Total > 0 {
+		line += fmt.Sprintf(" of %s (%.0f%%)", formatBytes(p.Total), 100*float64(p.Sent)/float64(p.Total))
+	}
+	line += " at " + formatBytes(int64(p.Rate)) + "/s"
+	if eta := p.ETA(); eta >= 0 && !p.Done {
+		line += ", " + eta.Round(time.Second).String() + " left"
+	}
+	if overallTotal > 0 {
+		line += fmt.Sprintf(", overall %.0f%%", 100*float64(overallSent)/float64(overallTotal))
+	}
+	// Pad to wipe what is left of a longer previous line
+	fmt.Printf("\r%-70s", line)
+	if p.Done {
+		fmt.Println()
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// loadDirDefaults sets the upload option flags not given on the command line
+// from the defaults file in dir, if there is one.
+func loadDirDefaults(dir string) error {
+	f, err := os.Open(filepath.Join(dir, dirDefaultsFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	allowed := make(map[string]bool)
+	for _, name := range uploadOptionFlags {
+		allowed[name] = true
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
-		if collectionId == "" {
-			fmt.Println("Creating collection " + requestTemplate.Collection)
-			additionalArgs := map[string]string{
-				"method": "flickr.collections.create",
-				"title":  requestTemplate.Collection,
-			}
-			request := flickr.NewRequest(http.MethodPost, requestTemplate.Auth, additionalArgs, requestTemplate.Secret)
-			response, err := request.ExecuteWithRetry(2, time.Second)
-			flickr.CheckErr(err, response)
-			var c flickr.Collection
-			flickr.CheckErr(xml.Unmarshal([]byte(response), &c))
-			collectionId = c.Id
+		kv := strings.SplitN(line, "=", 2)
+		name := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || !allowed[name] {
+			return errors.New("Wrong line in " + dirDefaultsFile + ": " + line)
 		}
-		fmt.Println("Adding album " + photosetid + " to collection")
-		additionalArgs = map[string]string{
-			"method":        "flickr.collections.addSet",
-			"collection_id": collectionId,
-			"photoset_id":   photosetid,
+		if given[name] {
+			continue
 		}
-		request = flickr.NewRequest(http.MethodPost, requestTemplate.Auth, additionalArgs, requestTemplate.Secret)
-		response, err = request.ExecuteWithRetry(2, time.Second)
-		if err != nil && err.Error() == "4: Set already in collection" {
-			fmt.Println("Album already in collection")
-		} else {
-			flickr.CheckErr(err, response)
+		if err := flag.Set(name, strings.TrimSpace(kv[1])); err != nil {
+			return err
 		}
 	}
+	return scanner.Err()
 }
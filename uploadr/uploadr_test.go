@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTagList(t *testing.T) {
+	var tags tagList
+	flags := flag.NewFlagSet("uploadr", flag.ContinueOnError)
+	flags.Var(&tags, "tags", "")
+	if err := flags.Parse([]string{"-tags", " a, b ,,c d,"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := (tagList{"a", "b", "c d"}); !reflect.DeepEqual(tags, want) {
+		t.Errorf("got %q, want %q", tags, want)
+	}
+}
+
+func TestLoadDirDefaults(t *testing.T) {
+	var tags tagList
+	flag.Var(&tags, "tags", "")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, dirDefaultsFile), []byte("# defaults\ntags = sea, sand ,\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadDirDefaults(dir); err != nil {
+		t.Fatal(err)
+	}
+	if want := (tagList{"sea", "sand"}); !reflect.DeepEqual(tags, want) {
+		t.Errorf("got %q, want %q", tags, want)
+	}
+	if err := os.WriteFile(filepath.Join(dir, dirDefaultsFile), []byte("album=x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadDirDefaults(dir); err == nil {
+		t.Error("expected a line for a flag that is not an upload option to fail")
+	}
+}